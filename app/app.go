@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
-	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/health"
 	"github.com/d0ugal/promexporter/logging"
 	"github.com/d0ugal/promexporter/metrics"
 	"github.com/d0ugal/promexporter/profiling"
@@ -30,6 +33,22 @@ type ConfigInterface interface {
 	GetProfiling() *config.ProfilingConfig
 }
 
+// MetricsConfigProvider is implemented by configs that embed BaseConfig.
+// Configs that don't implement it get the default metrics settings.
+type MetricsConfigProvider interface {
+	GetMetrics() *config.MetricsConfig
+}
+
+// metricsConfig returns the metrics settings of cfg, or the defaults if it
+// doesn't provide any
+func metricsConfig(cfg ConfigInterface) *config.MetricsConfig {
+	if provider, ok := cfg.(MetricsConfigProvider); ok {
+		return provider.GetMetrics()
+	}
+
+	return &config.MetricsConfig{}
+}
+
 // App represents the main application
 type App struct {
 	name        string
@@ -37,9 +56,27 @@ type App struct {
 	metrics     *metrics.Registry
 	server      *server.Server
 	collectors  []Collector
+	scheduled   []ScheduledCollector
+	probers     map[string]server.Prober
+	routes      []func(*server.Server) error
+	health      *health.Tracker
 	versionInfo *VersionInfo
 	tracer      *tracing.Tracer
 	profiler    *profiling.Profiler
+	pusher      *metrics.Pusher
+	listener    net.Listener
+	scheduler   *scheduler
+
+	// configMu guards config, which is replaced on reload
+	configMu        sync.RWMutex
+	reloadMu        sync.Mutex
+	configLoader    ConfigLoader
+	configWatchPath string
+	reloadOnSIGHUP  bool
+
+	// metricsOptionsSet records that the config has set registry options,
+	// so a reload that removes them also removes them from the registry
+	metricsOptionsSet bool
 }
 
 // VersionInfo holds version information for the application
@@ -55,10 +92,25 @@ type Collector interface {
 	Stop()
 }
 
+// GracefulStopper can be implemented alongside Collector by collectors whose
+// shutdown may block. Shutdown is called instead of Stop and should return
+// once the collector has stopped or ctx is done, whichever comes first.
+type GracefulStopper interface {
+	Shutdown(ctx context.Context) error
+}
+
+// HealthReporter can be implemented alongside Collector by collectors that
+// manage their own collection loop, to include their status in the /health
+// and /ready endpoints. Scheduled collectors are tracked automatically.
+type HealthReporter interface {
+	Health() health.Status
+}
+
 // New creates a new application instance
 func New(name string) *App {
 	return &App{
-		name: name,
+		name:   name,
+		health: health.NewTracker(),
 	}
 }
 
@@ -80,6 +132,52 @@ func (a *App) WithCollector(collector Collector) *App {
 	return a
 }
 
+// WithScheduledCollector adds a collector whose Collect method is called by
+// the application on the collector's interval
+func (a *App) WithScheduledCollector(collector ScheduledCollector) *App {
+	a.scheduled = append(a.scheduled, collector)
+	return a
+}
+
+// WithProber registers a prober for the /probe endpoint, used by the probe
+// modules in the config that name it
+func (a *App) WithProber(name string, prober server.Prober) *App {
+	if a.probers == nil {
+		a.probers = make(map[string]server.Prober)
+	}
+
+	a.probers[name] = prober
+
+	return a
+}
+
+// WithRoute adds a custom endpoint to the server, wrapped in middleware.
+// See server.Server.Handle.
+func (a *App) WithRoute(method, path string, handler http.Handler, middleware ...server.Middleware) *App {
+	a.routes = append(a.routes, func(s *server.Server) error {
+		return s.Handle(method, path, handler, middleware...)
+	})
+
+	return a
+}
+
+// WithRouteGroup adds the custom endpoints that register adds to a group
+// under prefix, all wrapped in middleware
+func (a *App) WithRouteGroup(prefix string, register func(*server.RouteGroup) error, middleware ...server.Middleware) *App {
+	a.routes = append(a.routes, func(s *server.Server) error {
+		return register(s.Group(prefix, middleware...))
+	})
+
+	return a
+}
+
+// WithListener makes the server serve on an existing listener instead of
+// binding server.host and server.port itself
+func (a *App) WithListener(listener net.Listener) *App {
+	a.listener = listener
+	return a
+}
+
 // WithVersionInfo sets custom version information for the application
 func (a *App) WithVersionInfo(version, commit, buildDate string) *App {
 	a.versionInfo = &VersionInfo{
@@ -91,6 +189,14 @@ func (a *App) WithVersionInfo(version, commit, buildDate string) *App {
 	return a
 }
 
+// currentConfig returns the active configuration
+func (a *App) currentConfig() ConfigInterface {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+
+	return a.config
+}
+
 // GetTracer returns the tracer instance. After Build() it is always non-nil:
 // when tracing is disabled it returns a no-op Tracer whose methods do nothing
 // and whose IsEnabled() reports false. Consumers can therefore call
@@ -108,6 +214,8 @@ func (a *App) Build() *App {
 		Format: loggingConfig.Format,
 	})
 
+	a.applyMetricsOptions(metricsConfig(a.config))
+
 	// Initialize tracing. NewTracer always returns a usable Tracer — when
 	// tracing is disabled (or initialisation fails) the returned value is a
 	// no-op whose IsEnabled() reports false. Storing it unconditionally lets
@@ -162,6 +270,17 @@ func (a *App) Build() *App {
 		}
 	}
 
+	// Initialize pushing to a Pushgateway or remote-write endpoint
+	if pushConfig := &metricsConfig(a.config).Push; pushConfig.IsEnabled() {
+		pusher, err := a.metrics.NewPusher(pushOptions(pushConfig, a.name))
+		if err != nil {
+			slog.Error("Failed to initialize pushing", "error", err)
+		} else {
+			a.pusher = pusher
+			slog.Info("Pushing metrics", "interval", pushConfig.GetInterval())
+		}
+	}
+
 	// Set version info metric
 	if a.versionInfo != nil {
 		// Use custom version info if provided
@@ -193,61 +312,296 @@ func (a *App) Build() *App {
 	}
 
 	a.server = server.New(a.config, a.metrics, a.name, serverVersionInfo, a.tracer)
+	a.server.SetHealthSource(a.collectorHealth)
+
+	for name, prober := range a.probers {
+		if err := a.server.RegisterProber(name, prober); err != nil {
+			slog.Error("Failed to register prober", "prober", name, "error", err)
+		}
+	}
+
+	for _, register := range a.routes {
+		if err := register(a.server); err != nil {
+			slog.Error("Failed to register route", "error", err)
+		}
+	}
 
 	return a
 }
 
-// Run starts the application
+// pushOptions converts the push config to metrics.PushOptions, using the
+// exporter name as the default job
+func pushOptions(cfg *config.PushConfig, name string) metrics.PushOptions {
+	opts := metrics.PushOptions{
+		Interval:   cfg.GetInterval(),
+		Timeout:    cfg.GetTimeout(),
+		MaxRetries: cfg.GetMaxRetries(),
+	}
+
+	if gw := cfg.Pushgateway; gw.URL != "" {
+		opts.Pushgateway = &metrics.PushgatewayOptions{
+			URL:      gw.URL,
+			Job:      pushJob(gw.Job, name),
+			Grouping: gw.Grouping,
+			Username: gw.Username,
+			Password: gw.Password.Value(),
+			Headers:  gw.HeaderValues(),
+		}
+	}
+
+	if rw := cfg.RemoteWrite; rw.URL != "" {
+		opts.RemoteWrite = &metrics.RemoteWriteOptions{
+			URL:            rw.URL,
+			Job:            pushJob(rw.Job, name),
+			ExternalLabels: rw.ExternalLabels,
+			Username:       rw.Username,
+			Password:       rw.Password.Value(),
+			Headers:        rw.HeaderValues(),
+		}
+	}
+
+	return opts
+}
+
+// pushJob returns job, or the exporter name with anything that isn't a
+// letter, digit, '_', '-' or '.' replaced, so it is safe in Pushgateway URLs
+func pushJob(job, name string) string {
+	if job != "" {
+		return job
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// applyMetricsOptions applies the namespace, const labels, relabel rules
+// and cardinality limits from cfg to the registry. Options the exporter set
+// in code are kept unless the config sets some of its own.
+func (a *App) applyMetricsOptions(cfg *config.MetricsConfig) {
+	opts := metrics.RegistryOptions{
+		Namespace:   cfg.Namespace,
+		ConstLabels: cfg.ConstLabels,
+	}
+
+	for _, rule := range cfg.Relabel {
+		opts.Relabel = append(opts.Relabel, metrics.RelabelRule{
+			Action:      rule.Action,
+			Regex:       rule.Regex,
+			Replacement: rule.Replacement,
+		})
+	}
+
+	limits := metrics.CardinalityLimits{
+		MaxSeries:          cfg.Cardinality.MaxSeries,
+		MaxSeriesPerMetric: cfg.Cardinality.MaxSeriesPerMetric,
+		MetricLimits:       cfg.Cardinality.MetricLimits,
+	}
+
+	configured := opts.Namespace != "" || len(opts.ConstLabels) > 0 || len(opts.Relabel) > 0 ||
+		limits.MaxSeries > 0 || limits.MaxSeriesPerMetric > 0 || len(limits.MetricLimits) > 0
+	if !configured && !a.metricsOptionsSet {
+		return
+	}
+
+	if err := a.metrics.SetOptions(opts); err != nil {
+		slog.Error("Failed to apply metrics options", "error", err)
+
+		return
+	}
+
+	if err := a.metrics.SetCardinalityLimits(limits); err != nil {
+		slog.Error("Failed to apply cardinality limits", "error", err)
+
+		return
+	}
+
+	a.metricsOptionsSet = true
+}
+
+// collectorHealth returns the status of every scheduled collector and every
+// collector that implements HealthReporter
+func (a *App) collectorHealth() []health.Status {
+	statuses := a.health.Statuses()
+
+	for _, collector := range a.collectors {
+		if reporter, ok := collector.(HealthReporter); ok {
+			statuses = append(statuses, reporter.Health())
+		}
+	}
+
+	return statuses
+}
+
+// SignalContext returns a context that is cancelled when the process
+// receives SIGINT or SIGTERM. Pass it to RunContext to get the same
+// behaviour as Run; the returned stop function releases the signal handler.
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, syscall.SIGINT, syscall.SIGTERM)
+}
+
+// Run starts the application and blocks until SIGINT or SIGTERM is received
+// or the server fails, then shuts everything down
 func (a *App) Run() error {
-	// Start collectors
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, stop := SignalContext(context.Background())
+	defer stop()
+
+	return a.RunContext(ctx)
+}
+
+// RunContext starts the application and blocks until ctx is done or the
+// server fails. It returns once everything has been torn down. Unlike Run it
+// does not install any signal handlers, so it can be used to embed an
+// exporter in a larger program or to drive it from tests.
+func (a *App) RunContext(ctx context.Context) error {
+	// Collectors get their own context so that they keep running while
+	// in-flight scrapes are drained, rather than stopping as soon as the
+	// caller's context is cancelled.
+	collectorCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
 	defer cancel()
 
 	for _, collector := range a.collectors {
-		collector.Start(ctx)
+		collector.Start(collectorCtx)
 	}
 
-	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Start scheduled collectors. Each one runs immediately and then on its
+	// own interval, falling back to the configured default interval.
+	scheduler := &scheduler{
+		collectors:      a.scheduled,
+		defaultInterval: metricsConfig(a.currentConfig()).Collection.DefaultInterval.Duration,
+		tracer:          a.tracer,
+		health:          a.health,
+	}
 
-	go func() {
-		<-sigChan
-		slog.Info("Shutting down gracefully...")
-		cancel()
+	if len(a.scheduled) > 0 {
+		scheduler.metrics = a.metrics.CollectorMetrics()
+	}
 
-		// Stop collectors
-		for _, collector := range a.collectors {
-			collector.Stop()
+	a.scheduler = scheduler
+	scheduler.start(collectorCtx)
+
+	if a.pusher != nil {
+		go a.pusher.Run(collectorCtx)
+	}
+
+	// Reload the configuration on file changes and SIGHUP if enabled
+	if a.configLoader != nil {
+		a.metrics.ReloadMetrics().LastSuccess.SetToCurrentTime()
+
+		go a.watchConfig(collectorCtx)
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		if a.listener != nil {
+			serverErr <- a.server.Serve(a.listener)
+		} else {
+			serverErr <- a.server.Start()
 		}
+	}()
 
-		// Shutdown tracing
-		if a.tracer != nil {
-			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer shutdownCancel()
+	var runErr error
 
-			if err := a.tracer.Shutdown(shutdownCtx); err != nil {
-				slog.Error("Failed to shutdown tracing gracefully", "error", err)
-			}
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down gracefully...")
+	case err := <-serverErr:
+		// http.ErrServerClosed is the expected return after a graceful
+		// shutdown, so don't treat it as a failure.
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server failed", "error", err)
+			runErr = err
 		}
+	}
+
+	a.shutdown(cancel, scheduler)
 
-		// Shutdown profiling
-		if a.profiler != nil {
-			a.profiler.Stop()
+	return runErr
+}
+
+// shutdown tears the application down in order: stop accepting scrapes and
+// drain in-flight requests, stop collectors, push the final values, then
+// flush tracing and profiling. The whole sequence is bounded by
+// server.shutdown_timeout.
+func (a *App) shutdown(cancelCollectors context.CancelFunc, scheduler *scheduler) {
+	timeout := a.currentConfig().GetServer().GetShutdownTimeout()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	slog.Info("Starting shutdown", "timeout", timeout)
+
+	// Stop accepting scrapes and drain in-flight requests
+	if err := a.server.ShutdownContext(ctx); err != nil {
+		slog.Error("Failed to shutdown server gracefully", "error", err)
+	}
+
+	// Stop collectors
+	cancelCollectors()
+	a.stopCollectors(ctx, scheduler)
+
+	// Push once more so the final values, e.g. of a batch job, aren't lost
+	if a.pusher != nil {
+		if err := a.pusher.Push(ctx); err != nil {
+			slog.Error("Failed to push metrics on shutdown", "error", err)
 		}
+	}
 
-		// Shutdown server
-		if err := a.server.Shutdown(); err != nil {
-			slog.Error("Failed to shutdown server gracefully", "error", err)
+	// Flush tracing
+	if a.tracer != nil {
+		if err := a.tracer.Shutdown(ctx); err != nil {
+			slog.Error("Failed to shutdown tracing gracefully", "error", err)
 		}
-	}()
+	}
 
-	// Start server. http.ErrServerClosed is the expected return after a
-	// graceful shutdown, so don't treat it as a failure.
-	if err := a.server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		slog.Error("Server failed", "error", err)
-		return err
+	// Shutdown profiling
+	if a.profiler != nil {
+		a.profiler.Stop()
 	}
 
-	return nil
+	slog.Info("Shutdown complete")
+}
+
+// stopCollectors stops all collectors in parallel and waits for them, and
+// for any in-progress scheduled collection, to finish or for ctx to be done
+func (a *App) stopCollectors(ctx context.Context, scheduler *scheduler) {
+	var wg sync.WaitGroup
+
+	for _, collector := range a.collectors {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if stopper, ok := collector.(GracefulStopper); ok {
+				if err := stopper.Shutdown(ctx); err != nil {
+					slog.Error("Failed to stop collector gracefully", "error", err)
+				}
+
+				return
+			}
+
+			collector.Stop()
+		}()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		scheduler.wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("Timed out waiting for collectors to stop", "error", ctx.Err())
+	}
 }
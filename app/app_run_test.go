@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"net"
+	"net/http"
 	"os"
 	"syscall"
 	"testing"
@@ -63,3 +66,132 @@ func TestRun_GracefulShutdownReturnsNil(t *testing.T) {
 		t.Fatal("timed out waiting for Run() to return after SIGTERM")
 	}
 }
+
+// blockingCollector never stops on its own; its Shutdown only returns when
+// the shutdown deadline expires.
+type blockingCollector struct {
+	shutdownCalled chan struct{}
+}
+
+func (b *blockingCollector) Start(ctx context.Context) {}
+func (b *blockingCollector) Stop()                     {}
+
+func (b *blockingCollector) Shutdown(ctx context.Context) error {
+	close(b.shutdownCalled)
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+// TestRun_ShutdownTimeoutBoundsCollectorStop verifies a collector that hangs
+// on shutdown cannot block Run past server.shutdown_timeout.
+func TestRun_ShutdownTimeoutBoundsCollectorStop(t *testing.T) {
+	cfg := &mockConfig{
+		logging: &config.LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		server: &config.ServerConfig{
+			Host:            "127.0.0.1",
+			Port:            0,
+			ShutdownTimeout: config.Duration{Duration: 200 * time.Millisecond},
+		},
+	}
+
+	collector := &blockingCollector{shutdownCalled: make(chan struct{})}
+
+	a := New("test-exporter").
+		WithConfig(cfg).
+		WithMetrics(metrics.NewRegistry("test_exporter_info")).
+		WithCollector(collector).
+		Build()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.Run()
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess: %v", err)
+	}
+
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal SIGTERM: %v", err)
+	}
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("expected nil error on graceful shutdown, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return within the shutdown timeout")
+	}
+
+	select {
+	case <-collector.shutdownCalled:
+	default:
+		t.Error("expected Shutdown(ctx) to be called instead of Stop()")
+	}
+}
+
+// TestRunContext_ServesUntilCancelled drives the app entirely through a
+// context and a caller-provided listener: no signals and no sleeping while
+// waiting for the server to bind.
+func TestRunContext_ServesUntilCancelled(t *testing.T) {
+	cfg := &mockConfig{
+		logging: &config.LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		server: &config.ServerConfig{Host: "127.0.0.1"},
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	a := New("test-exporter").
+		WithConfig(cfg).
+		WithMetrics(metrics.NewRegistry("test_exporter_info")).
+		WithListener(listener).
+		Build()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.RunContext(ctx)
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics: want 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("expected nil error after cancellation, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for RunContext() to return after cancellation")
+	}
+
+	if _, err := http.Get("http://" + listener.Addr().String() + "/metrics"); err == nil {
+		t.Error("expected server to be closed after RunContext returned")
+	}
+}
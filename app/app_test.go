@@ -14,6 +14,7 @@ import (
 // mockConfig implements ConfigInterface for testing
 type mockConfig struct {
 	logging *config.LoggingConfig
+	metrics *config.MetricsConfig
 	server  *config.ServerConfig
 	tracing *config.TracingConfig
 }
@@ -26,6 +27,14 @@ func (m *mockConfig) GetLogging() *config.LoggingConfig {
 	return m.logging
 }
 
+func (m *mockConfig) GetMetrics() *config.MetricsConfig {
+	if m.metrics == nil {
+		return &config.MetricsConfig{}
+	}
+
+	return m.metrics
+}
+
 func (m *mockConfig) GetServer() *config.ServerConfig {
 	return m.server
 }
@@ -179,3 +188,45 @@ test_exporter_info{build_date="unknown",commit="unknown",version="dev"} 1
 		t.Errorf("Version info metric mismatch:\n%s", err)
 	}
 }
+
+// TestPushOptions_DefaultJob asserts the exporter name is made safe for
+// Pushgateway URLs when it is used as the job, and a configured job is kept.
+func TestPushOptions_DefaultJob(t *testing.T) {
+	cfg := &config.PushConfig{
+		Pushgateway: config.PushgatewayConfig{URL: "http://pushgateway:9091"},
+		RemoteWrite: config.RemoteWriteConfig{URL: "http://prometheus:9090/api/v1/write", Job: "backup"},
+	}
+
+	opts := pushOptions(cfg, "my exporter/v2")
+
+	if got := opts.Pushgateway.Job; got != "my_exporter_v2" {
+		t.Errorf("expected a sanitised default job, got %q", got)
+	}
+
+	if got := opts.RemoteWrite.Job; got != "backup" {
+		t.Errorf("expected the configured job, got %q", got)
+	}
+}
+
+// TestBuild_ConfigWithoutMetrics asserts a config that doesn't provide
+// metrics settings still works, with the defaults.
+func TestBuild_ConfigWithoutMetrics(t *testing.T) {
+	// Embedding the interface hides mockConfig's GetMetrics
+	cfg := struct{ ConfigInterface }{&mockConfig{
+		logging: &config.LoggingConfig{Level: "info", Format: "json"},
+		server:  &config.ServerConfig{Host: "localhost", Port: 8080},
+	}}
+
+	if _, ok := ConfigInterface(cfg).(MetricsConfigProvider); ok {
+		t.Fatal("expected the test config not to provide metrics settings")
+	}
+
+	a := New("test-exporter").
+		WithConfig(cfg).
+		WithMetrics(metrics.NewRegistry("test_exporter_info")).
+		Build()
+
+	if got := metricsConfig(a.config).Collection.DefaultInterval.Duration; got != 0 {
+		t.Errorf("expected the default metrics settings, got interval %v", got)
+	}
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/d0ugal/promexporter/logging"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce is how long file change events are coalesced before a
+// reload is triggered. Editors and Kubernetes ConfigMap updates typically
+// produce several events for a single change.
+const reloadDebounce = 500 * time.Millisecond
+
+// ConfigLoader loads a fresh copy of the configuration, typically by calling
+// config.Load with the same path that was used at startup
+type ConfigLoader func() (ConfigInterface, error)
+
+// Reloadable can be implemented alongside Collector or ScheduledCollector by
+// collectors that need to be notified when the configuration is reloaded
+type Reloadable interface {
+	Reload(cfg ConfigInterface) error
+}
+
+// validator is implemented by configurations with a Validate method, such
+// as anything embedding config.BaseConfig
+type validator interface {
+	Validate() error
+}
+
+// WithConfigReload enables reloading the configuration with loader, either
+// through Reload or on the triggers WithConfigWatch and WithReloadOnSIGHUP add
+func (a *App) WithConfigReload(loader ConfigLoader) *App {
+	a.configLoader = loader
+	return a
+}
+
+// WithReloadOnSIGHUP also reloads the configuration when the process
+// receives SIGHUP. Signal handling is process-wide, so it is left to the
+// exporter to opt in. It has no effect unless WithConfigReload is also used.
+func (a *App) WithReloadOnSIGHUP() *App {
+	a.reloadOnSIGHUP = true
+	return a
+}
+
+// WithConfigWatch also reloads the configuration when the file at path
+// changes. It has no effect unless WithConfigReload is also used.
+func (a *App) WithConfigWatch(path string) *App {
+	a.configWatchPath = path
+	return a
+}
+
+// Reload loads, validates and applies a new configuration. If loading or
+// validation fails the current configuration is kept.
+func (a *App) Reload() error {
+	if a.configLoader == nil {
+		return errors.New("config reload is not enabled")
+	}
+
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	reloadMetrics := a.metrics.ReloadMetrics()
+
+	cfg, err := a.configLoader()
+	if err == nil {
+		if v, ok := cfg.(validator); ok {
+			err = v.Validate()
+		}
+	}
+
+	if err != nil {
+		reloadMetrics.Failures.Inc()
+		slog.Error("Config reload failed, keeping current configuration", "error", err)
+
+		return fmt.Errorf("config reload failed: %w", err)
+	}
+
+	a.applyConfig(cfg)
+	reloadMetrics.LastSuccess.SetToCurrentTime()
+
+	slog.Info("Configuration reloaded")
+
+	return nil
+}
+
+// applyConfig swaps in a new configuration and notifies everything that
+// depends on it
+func (a *App) applyConfig(cfg ConfigInterface) {
+	previous := a.currentConfig()
+
+	a.configMu.Lock()
+	a.config = cfg
+	a.configMu.Unlock()
+
+	// A level change only needs the shared level updating; the handler is
+	// rebuilt only when the format changes
+	loggingConfig := cfg.GetLogging()
+	if loggingConfig.Format == previous.GetLogging().Format {
+		if err := logging.SetLevel(loggingConfig.Level); err != nil {
+			slog.Warn("Failed to apply reloaded log level", "error", err)
+		}
+	} else {
+		logging.Configure(&logging.Config{
+			Level:  loggingConfig.Level,
+			Format: loggingConfig.Format,
+		})
+	}
+
+	if previous.GetServer().Host != cfg.GetServer().Host || previous.GetServer().Port != cfg.GetServer().Port {
+		slog.Warn("Server address changes require a restart to take effect")
+	}
+
+	a.applyMetricsOptions(metricsConfig(cfg))
+	a.server.SetConfig(cfg)
+
+	if a.scheduler != nil {
+		a.scheduler.setDefaultInterval(metricsConfig(cfg).Collection.DefaultInterval.Duration)
+	}
+
+	for _, collector := range a.collectors {
+		notifyReload(collector, cfg)
+	}
+
+	for _, collector := range a.scheduled {
+		notifyReload(collector, cfg)
+	}
+}
+
+// notifyReload passes cfg to collector if it implements Reloadable. Errors
+// are logged; they don't roll back the new configuration.
+func notifyReload(collector interface{}, cfg ConfigInterface) {
+	reloadable, ok := collector.(Reloadable)
+	if !ok {
+		return
+	}
+
+	if err := reloadable.Reload(cfg); err != nil {
+		slog.Error("Collector failed to apply reloaded configuration", "error", err)
+	}
+}
+
+// watchConfig triggers a reload on SIGHUP, if enabled, and on changes to the
+// watch path, if set. It returns when ctx is done.
+func (a *App) watchConfig(ctx context.Context) {
+	var hup chan os.Signal
+
+	if a.reloadOnSIGHUP {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+
+		defer signal.Stop(hup)
+	}
+
+	var fileEvents <-chan struct{}
+
+	if a.configWatchPath != "" {
+		events, err := watchFile(ctx, a.configWatchPath)
+		if err != nil {
+			slog.Error("Failed to watch config file",
+				"path", a.configWatchPath,
+				"error", err,
+			)
+		} else {
+			fileEvents = events
+
+			slog.Info("Watching config file for changes", "path", a.configWatchPath)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("Received SIGHUP, reloading configuration")
+
+			_ = a.Reload()
+		case <-fileEvents:
+			slog.Info("Config file changed, reloading configuration", "path", a.configWatchPath)
+
+			_ = a.Reload()
+		}
+	}
+}
+
+// watchFile sends on the returned channel whenever path changes, after
+// debouncing bursts of events. The parent directory is watched rather than
+// the file itself so that atomic replacements (rename over the file, or the
+// symlink swap Kubernetes uses for mounted ConfigMaps) are detected.
+func watchFile(ctx context.Context, path string) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, base := filepath.Split(filepath.Clean(path))
+	if dir == "" {
+		dir = "."
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	changes := make(chan struct{})
+
+	go func() {
+		defer func() { _ = watcher.Close() }()
+
+		debounce := time.NewTimer(reloadDebounce)
+		debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				debounce.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				name := filepath.Base(event.Name)
+				if name == base || name == "..data" {
+					debounce.Reset(reloadDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				slog.Warn("Config file watcher error", "error", err)
+			case <-debounce.C:
+				select {
+				case changes <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// reloadableCollector records the configurations it is notified about
+type reloadableCollector struct {
+	reloaded []ConfigInterface
+}
+
+func (r *reloadableCollector) Name() string                      { return "reloadable" }
+func (r *reloadableCollector) Interval() time.Duration           { return time.Hour }
+func (r *reloadableCollector) Collect(ctx context.Context) error { return nil }
+
+func (r *reloadableCollector) Reload(cfg ConfigInterface) error {
+	r.reloaded = append(r.reloaded, cfg)
+	return nil
+}
+
+func newReloadTestConfig(level string) *config.BaseConfig {
+	return &config.BaseConfig{
+		Server:  config.ServerConfig{Host: "127.0.0.1", Port: 8080},
+		Logging: config.LoggingConfig{Level: level, Format: "json"},
+		Metrics: config.MetricsConfig{
+			Collection: config.CollectionConfig{DefaultInterval: config.Duration{Duration: time.Minute}},
+		},
+	}
+}
+
+// TestReload_AppliesValidConfig verifies a successful reload swaps the
+// configuration, notifies Reloadable collectors and records the success.
+func TestReload_AppliesValidConfig(t *testing.T) {
+	registry := metrics.NewRegistry("reload_exporter_info")
+	collector := &reloadableCollector{}
+	next := newReloadTestConfig("debug")
+
+	a := New("test-exporter").
+		WithConfig(newReloadTestConfig("info")).
+		WithMetrics(registry).
+		WithScheduledCollector(collector).
+		WithConfigReload(func() (ConfigInterface, error) { return next, nil }).
+		Build()
+
+	if err := a.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if a.currentConfig() != next {
+		t.Error("expected the reloaded configuration to be active")
+	}
+
+	if len(collector.reloaded) != 1 || collector.reloaded[0] != next {
+		t.Errorf("expected collector to be notified once with the new config, got %v", collector.reloaded)
+	}
+
+	if got := testutil.ToFloat64(registry.ReloadMetrics().LastSuccess); got == 0 {
+		t.Error("expected last reload success timestamp to be set")
+	}
+}
+
+// TestReload_KeepsConfigOnFailure verifies that load and validation errors
+// leave the current configuration in place and are counted.
+func TestReload_KeepsConfigOnFailure(t *testing.T) {
+	registry := metrics.NewRegistry("reload_exporter_info")
+	collector := &reloadableCollector{}
+	initial := newReloadTestConfig("info")
+
+	loads := []func() (ConfigInterface, error){
+		func() (ConfigInterface, error) { return nil, errors.New("file not found") },
+		func() (ConfigInterface, error) { return newReloadTestConfig("verbose"), nil }, // fails Validate
+	}
+	attempt := 0
+
+	a := New("test-exporter").
+		WithConfig(initial).
+		WithMetrics(registry).
+		WithScheduledCollector(collector).
+		WithConfigReload(func() (ConfigInterface, error) {
+			load := loads[attempt]
+			attempt++
+
+			return load()
+		}).
+		Build()
+
+	for range loads {
+		if err := a.Reload(); err == nil {
+			t.Fatal("expected Reload to fail")
+		}
+	}
+
+	if a.currentConfig() != initial {
+		t.Error("expected the initial configuration to be kept")
+	}
+
+	if len(collector.reloaded) != 0 {
+		t.Error("collectors must not be notified about failed reloads")
+	}
+
+	if got := testutil.ToFloat64(registry.ReloadMetrics().Failures); got != 2 {
+		t.Errorf("expected 2 reload failures, got %v", got)
+	}
+}
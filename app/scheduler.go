@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/promexporter/health"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/d0ugal/promexporter/tracing"
+)
+
+// jitterFraction is the maximum amount, as a fraction of the interval, that
+// each scheduled run is shifted by. This spreads load when many exporters
+// (or many collectors in one exporter) share the same interval.
+const jitterFraction = 0.1
+
+// fallbackInterval is used when neither the collector nor the configuration
+// provide an interval. It matches the config package's default.
+const fallbackInterval = 30 * time.Second
+
+// ScheduledCollector is a collector whose collection loop is driven by the
+// App. Implementations only gather data once per call to Collect; the App
+// takes care of the ticker, jitter and preventing overlapping runs.
+type ScheduledCollector interface {
+	// Name identifies the collector in logs and traces
+	Name() string
+	// Interval returns how often Collect should run. Zero (or a negative
+	// value) falls back to metrics.collection.default_interval.
+	Interval() time.Duration
+	// Collect performs a single collection cycle
+	Collect(ctx context.Context) error
+}
+
+// OptionalCollector can be implemented alongside ScheduledCollector to take
+// a collector out of the readiness check. Scheduled collectors that don't
+// implement it are required: /ready reports not ready until they have
+// succeeded and while they are failing.
+type OptionalCollector interface {
+	// Required reports whether /ready should wait for the collector
+	Required() bool
+}
+
+// scheduler runs ScheduledCollectors on their configured interval
+type scheduler struct {
+	collectors      []ScheduledCollector
+	defaultInterval time.Duration
+	intervalMu      sync.RWMutex
+	tracer          *tracing.Tracer
+	metrics         *metrics.CollectorMetrics
+	health          *health.Tracker
+	wg              sync.WaitGroup
+}
+
+// start launches one goroutine per collector. Each goroutine runs until ctx
+// is cancelled.
+func (s *scheduler) start(ctx context.Context) {
+	for _, collector := range s.collectors {
+		if s.metrics != nil {
+			s.metrics.Init(collector.Name())
+		}
+
+		if s.health != nil {
+			s.health.Register(collector.Name(), isRequired(collector))
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			s.loop(ctx, collector)
+		}()
+	}
+}
+
+// isRequired reports whether collector must succeed for the exporter to be
+// ready
+func isRequired(collector ScheduledCollector) bool {
+	if optional, ok := collector.(OptionalCollector); ok {
+		return optional.Required()
+	}
+
+	return true
+}
+
+// wait blocks until every collector goroutine has exited
+func (s *scheduler) wait() {
+	s.wg.Wait()
+}
+
+// setDefaultInterval changes the default interval, e.g. after a config
+// reload. It takes effect from each collector's next run.
+func (s *scheduler) setDefaultInterval(interval time.Duration) {
+	s.intervalMu.Lock()
+	defer s.intervalMu.Unlock()
+
+	s.defaultInterval = interval
+}
+
+// intervalFor returns the effective interval for a collector
+func (s *scheduler) intervalFor(collector ScheduledCollector) time.Duration {
+	if interval := collector.Interval(); interval > 0 {
+		return interval
+	}
+
+	s.intervalMu.RLock()
+	defer s.intervalMu.RUnlock()
+
+	if s.defaultInterval > 0 {
+		return s.defaultInterval
+	}
+
+	return fallbackInterval
+}
+
+// loop runs the collector immediately and then once per interval. Runs are
+// strictly sequential: the next run is only scheduled once the previous one
+// has returned, so a slow collection delays the next run rather than
+// overlapping with it. The interval is re-evaluated after every run so that
+// changes made by a config reload are picked up.
+func (s *scheduler) loop(ctx context.Context, collector ScheduledCollector) {
+	slog.Info("Starting scheduled collector",
+		"collector", collector.Name(),
+		"interval", s.intervalFor(collector),
+	)
+
+	s.runOnce(ctx, collector)
+
+	timer := time.NewTimer(withJitter(s.intervalFor(collector)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping scheduled collector", "collector", collector.Name())
+			return
+		case <-timer.C:
+			s.runOnce(ctx, collector)
+			timer.Reset(withJitter(s.intervalFor(collector)))
+		}
+	}
+}
+
+// runOnce performs a single collection, recovering from panics so that one
+// misbehaving collector cannot take down the whole exporter.
+func (s *scheduler) runOnce(ctx context.Context, collector ScheduledCollector) {
+	name := collector.Name()
+	start := time.Now()
+
+	collectorSpan := s.tracer.NewCollectorSpan(ctx, name, "collect")
+	defer collectorSpan.End()
+
+	err := safeCollect(collectorSpan.Context(), collector)
+	duration := time.Since(start)
+
+	if s.metrics != nil {
+		s.metrics.Observe(name, duration.Seconds(), err)
+	}
+
+	if s.health != nil {
+		if err != nil {
+			s.health.RecordFailure(name, err, time.Now())
+		} else {
+			s.health.RecordSuccess(name, time.Now())
+		}
+	}
+
+	if err != nil {
+		collectorSpan.RecordError(err)
+		slog.Error("Collection failed",
+			"collector", name,
+			"duration", duration,
+			"error", err,
+		)
+
+		return
+	}
+
+	slog.Debug("Collection completed",
+		"collector", name,
+		"duration", duration,
+	)
+}
+
+// safeCollect calls Collect and converts a panic into an error
+func safeCollect(ctx context.Context, collector ScheduledCollector) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("collector panicked: %v", r)
+		}
+	}()
+
+	return collector.Collect(ctx)
+}
+
+// withJitter shifts interval by a random amount of up to ±jitterFraction
+func withJitter(interval time.Duration) time.Duration {
+	maxJitter := int64(float64(interval) * jitterFraction)
+	if maxJitter <= 0 {
+		return interval
+	}
+
+	return interval + time.Duration(rand.Int64N(2*maxJitter+1)-maxJitter) //nolint:gosec // Jitter does not need a CSPRNG
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/promexporter/health"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/d0ugal/promexporter/tracing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// countingCollector records how many times Collect ran and how many runs
+// were in flight at once.
+type countingCollector struct {
+	interval time.Duration
+	delay    time.Duration
+	err      error
+	runs     atomic.Int32
+	inFlight atomic.Int32
+	overlap  atomic.Bool
+}
+
+func (c *countingCollector) Name() string            { return "counting" }
+func (c *countingCollector) Interval() time.Duration { return c.interval }
+
+func (c *countingCollector) Collect(ctx context.Context) error {
+	if c.inFlight.Add(1) > 1 {
+		c.overlap.Store(true)
+	}
+	defer c.inFlight.Add(-1)
+
+	c.runs.Add(1)
+
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+	}
+
+	return c.err
+}
+
+// TestScheduler_RunsImmediately verifies the first collection happens as soon
+// as the scheduler starts rather than after the first interval elapses.
+func TestScheduler_RunsImmediately(t *testing.T) {
+	collector := &countingCollector{interval: time.Hour}
+
+	s := &scheduler{
+		collectors: []ScheduledCollector{collector},
+		tracer:     &tracing.Tracer{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.start(ctx)
+
+	deadline := time.After(2 * time.Second)
+	for collector.runs.Load() == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("collector was not run immediately")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	s.wait()
+
+	if got := collector.runs.Load(); got != 1 {
+		t.Errorf("expected exactly 1 run with a 1h interval, got %d", got)
+	}
+}
+
+// TestScheduler_NoOverlap verifies that a collection slower than its interval
+// delays the next run instead of running concurrently with it.
+func TestScheduler_NoOverlap(t *testing.T) {
+	collector := &countingCollector{
+		interval: 5 * time.Millisecond,
+		delay:    20 * time.Millisecond,
+		err:      errors.New("always fails"),
+	}
+
+	s := &scheduler{
+		collectors: []ScheduledCollector{collector},
+		tracer:     &tracing.Tracer{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	s.start(ctx)
+	s.wait()
+
+	if collector.overlap.Load() {
+		t.Error("collector runs overlapped")
+	}
+
+	if got := collector.runs.Load(); got < 2 {
+		t.Errorf("expected failing collector to keep being scheduled, got %d runs", got)
+	}
+}
+
+func TestScheduler_IntervalFallback(t *testing.T) {
+	s := &scheduler{defaultInterval: 10 * time.Second}
+
+	if got := s.intervalFor(&countingCollector{}); got != 10*time.Second {
+		t.Errorf("expected default interval, got %s", got)
+	}
+
+	if got := s.intervalFor(&countingCollector{interval: time.Second}); got != time.Second {
+		t.Errorf("expected collector interval, got %s", got)
+	}
+
+	s.defaultInterval = 0
+	if got := s.intervalFor(&countingCollector{}); got != fallbackInterval {
+		t.Errorf("expected fallback interval, got %s", got)
+	}
+}
+
+// TestScheduler_RecordsMetrics verifies every run is reflected in the
+// collector self-metrics published on the registry.
+func TestScheduler_RecordsMetrics(t *testing.T) {
+	registry := metrics.NewRegistry("sched_exporter_info")
+	collectorMetrics := registry.CollectorMetrics()
+
+	failing := &countingCollector{interval: time.Hour, err: errors.New("boom")}
+
+	s := &scheduler{
+		collectors: []ScheduledCollector{failing},
+		tracer:     &tracing.Tracer{},
+		metrics:    collectorMetrics,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.start(ctx)
+
+	deadline := time.After(2 * time.Second)
+	for testutil.ToFloat64(collectorMetrics.Runs.WithLabelValues("counting")) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("collector run was not recorded")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	s.wait()
+
+	if got := testutil.ToFloat64(collectorMetrics.Errors.WithLabelValues("counting")); got != 1 {
+		t.Errorf("expected 1 error, got %v", got)
+	}
+
+	if got := testutil.CollectAndCount(collectorMetrics.LastSuccess); got != 0 {
+		t.Errorf("expected no last success series for a failing collector, got %d", got)
+	}
+
+	found := false
+
+	for _, info := range registry.GetMetricsInfo() {
+		if info.Name == "sched_exporter_collector_runs_total" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Error("collector metrics were not added to the UI metric list")
+	}
+}
+
+// optionalCollector is a failing collector that opts out of readiness
+type optionalCollector struct {
+	countingCollector
+}
+
+func (c *optionalCollector) Name() string   { return "optional" }
+func (c *optionalCollector) Required() bool { return false }
+
+// TestScheduler_OptionalCollectorDoesNotBlockReadiness verifies a failing
+// collector that implements OptionalCollector doesn't make /ready fail.
+func TestScheduler_OptionalCollectorDoesNotBlockReadiness(t *testing.T) {
+	tracker := health.NewTracker()
+	optional := &optionalCollector{countingCollector{interval: time.Hour, err: errors.New("boom")}}
+
+	s := &scheduler{
+		collectors: []ScheduledCollector{optional},
+		tracer:     &tracing.Tracer{},
+		health:     tracker,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.start(ctx)
+
+	deadline := time.After(2 * time.Second)
+	for optional.runs.Load() == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("collector did not run")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	s.wait()
+
+	statuses := tracker.Statuses()
+	if len(statuses) != 1 || statuses[0].Required {
+		t.Fatalf("expected the collector to be tracked as optional, got %+v", statuses)
+	}
+
+	if !health.AllReady(statuses) {
+		t.Error("expected a failing optional collector not to block readiness")
+	}
+}
@@ -0,0 +1,211 @@
+// Package cli provides the command line flags and startup glue shared by
+// every exporter: loading the configuration and handling -version,
+// -show-config, -check-config and -config-schema.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/logging"
+	"github.com/d0ugal/promexporter/version"
+)
+
+// Exit codes used when the process should not go on to run the exporter
+const (
+	ExitOK          = 0
+	ExitConfigError = 1
+	ExitUsage       = 2
+)
+
+// DefaultConfigPath is loaded when no -config flag is given, if it exists
+const DefaultConfigPath = "config.yaml"
+
+// Config is implemented by any struct embedding config.BaseConfig
+type Config interface {
+	GetDisplayConfig() map[string]interface{}
+	Provenance() map[string]string
+}
+
+// Options describe the exporter to the CLI
+type Options struct {
+	Name         string        // Exporter name shown by -version
+	EnvPrefix    string        // Optional prefix for environment variables, e.g. "MYEXP_"
+	Version      *version.Info // Defaults to version.Get()
+	StrictConfig bool          // Reject unknown keys in config files as likely typos (always on for -check-config)
+
+	// Output streams, defaulting to os.Stdout and os.Stderr
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Load parses the process's arguments and loads cfg, exiting the process for
+// -version, -show-config, -check-config, -help and errors. It returns the
+// loader that was used so the exporter can reload the configuration later.
+func Load(cfg Config, opts Options) *config.Loader {
+	loader, code, exit := Run(os.Args[1:], cfg, opts)
+	if exit {
+		os.Exit(code)
+	}
+
+	return loader
+}
+
+// Run parses args and loads cfg. When the exporter should not start, exit is
+// true and code is the process exit code.
+func Run(args []string, cfg Config, opts Options) (loader *config.Loader, code int, exit bool) {
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	var (
+		configFiles   config.FlagList
+		overrides     config.FlagList
+		showVersion   bool
+		configFromEnv bool
+		showConfig    bool
+		checkConfig   bool
+		configSchema  bool
+	)
+
+	flags := flag.NewFlagSet(opts.Name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&showVersion, "version", false, "Show version information")
+	flags.BoolVar(&showVersion, "v", false, "Show version information")
+	flags.Var(&configFiles, "config", "Path to a configuration file (repeatable; later files take precedence, default: "+DefaultConfigPath+")")
+	flags.BoolVar(&configFromEnv, "config-from-env", false, "Ignore configuration files and use environment variables and -set only")
+	flags.Var(&overrides, "set", "Override a configuration value, e.g. -set server.port=9100 (repeatable)")
+	flags.BoolVar(&showConfig, "show-config", false, "Show the loaded configuration and where each value came from, then exit")
+	flags.BoolVar(&checkConfig, "check-config", false, "Validate the configuration and exit")
+	flags.BoolVar(&configSchema, "config-schema", false, "Print a JSON Schema for the configuration file and exit")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, ExitOK, true
+		}
+
+		return nil, ExitUsage, true
+	}
+
+	if showVersion {
+		printVersion(stdout, opts)
+		return nil, ExitOK, true
+	}
+
+	if configSchema {
+		schema, err := config.JSONSchema(cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return nil, ExitConfigError, true
+		}
+
+		_, _ = fmt.Fprintln(stdout, string(schema))
+
+		return nil, ExitOK, true
+	}
+
+	if configFromEnv || os.Getenv("CONFIG_FROM_ENV") == "true" {
+		configFiles = nil
+	} else if len(configFiles) == 0 {
+		if _, err := os.Stat(DefaultConfigPath); err == nil {
+			configFiles = config.FlagList{DefaultConfigPath}
+		}
+	}
+
+	// -check-config always decodes strictly, so it catches typos in keys
+	loader = &config.Loader{
+		Files:     configFiles,
+		EnvPrefix: opts.EnvPrefix,
+		Overrides: overrides,
+		Strict:    opts.StrictConfig || checkConfig,
+	}
+
+	if err := loader.Load(cfg); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return loader, ExitConfigError, true
+	}
+
+	if showConfig {
+		printConfig(stdout, cfg)
+		return loader, ExitOK, true
+	}
+
+	if checkConfig {
+		_, _ = fmt.Fprintln(stdout, "Configuration is valid")
+		return loader, ExitOK, true
+	}
+
+	return loader, ExitOK, false
+}
+
+// printVersion writes the -version output
+func printVersion(w io.Writer, opts Options) {
+	info := opts.Version
+	if info == nil {
+		defaultInfo := version.Get()
+		info = &defaultInfo
+	}
+
+	goVersion := info.GoVersion
+	if goVersion == "" {
+		goVersion = version.Get().GoVersion
+	}
+
+	_, _ = fmt.Fprintf(w, "%s %s\n", opts.Name, info.Version)
+	_, _ = fmt.Fprintf(w, "Commit: %s\n", info.Commit)
+	_, _ = fmt.Fprintf(w, "Build Date: %s\n", info.BuildDate)
+	_, _ = fmt.Fprintf(w, "Go Version: %s\n", goVersion)
+}
+
+// printConfig writes the -show-config output. Values come from
+// GetDisplayConfig, so sensitive values are redacted.
+func printConfig(w io.Writer, cfg Config) {
+	_, _ = fmt.Fprintln(w, "Configuration:")
+
+	printSection(w, cfg.GetDisplayConfig(), "  ")
+
+	_, _ = fmt.Fprintln(w, "Sources (values not left at their defaults):")
+
+	for _, line := range config.FormatProvenance(cfg.Provenance()) {
+		_, _ = fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// printSection writes the entries of a display config section in key order,
+// indenting nested sections
+func printSection(w io.Writer, section map[string]interface{}, indent string) {
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if nested, ok := section[key].(map[string]interface{}); ok {
+			_, _ = fmt.Fprintf(w, "%s%s:\n", indent, key)
+			printSection(w, nested, indent+"  ")
+
+			continue
+		}
+
+		// Sensitive values print redacted themselves; anything else is
+		// redacted as it would be in the web UI
+		value := section[key]
+		if _, ok := value.(config.SensitiveValue); !ok {
+			value = logging.RedactValue(key, value)
+		}
+
+		_, _ = fmt.Fprintf(w, "%s%s: %v\n", indent, key, value)
+	}
+}
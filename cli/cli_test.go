@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/version"
+)
+
+// run calls Run with captured output, from a temp directory so no
+// config.yaml is picked up by default
+func run(t *testing.T, cfg Config, args ...string) (code int, exit bool, stdout, stderr string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	var out, errOut bytes.Buffer
+
+	_, code, exit = Run(args, cfg, Options{
+		Name:    "test-exporter",
+		Version: &version.Info{Version: "v1.2.3", Commit: "abc123", BuildDate: "2026-01-01"},
+		Stdout:  &out,
+		Stderr:  &errOut,
+	})
+
+	return code, exit, out.String(), errOut.String()
+}
+
+func TestRun_Version(t *testing.T) {
+	code, exit, stdout, _ := run(t, &config.BaseConfig{}, "-version")
+	if !exit || code != ExitOK {
+		t.Fatalf("want exit with code %d, got exit=%v code=%d", ExitOK, exit, code)
+	}
+
+	if !strings.Contains(stdout, "test-exporter v1.2.3") || !strings.Contains(stdout, "abc123") {
+		t.Errorf("unexpected version output: %q", stdout)
+	}
+}
+
+func TestRun_CheckConfig(t *testing.T) {
+	code, exit, stdout, _ := run(t, &config.BaseConfig{}, "-check-config")
+	if !exit || code != ExitOK || !strings.Contains(stdout, "valid") {
+		t.Errorf("valid config: got exit=%v code=%d output=%q", exit, code, stdout)
+	}
+
+	code, exit, _, stderr := run(t, &config.BaseConfig{}, "-check-config", "-set", "server.port=99999")
+	if !exit || code != ExitConfigError || !strings.Contains(stderr, "port") {
+		t.Errorf("invalid config: got exit=%v code=%d stderr=%q", exit, code, stderr)
+	}
+}
+
+// TestRun_CheckConfigRejectsUnknownKeys asserts -check-config reports a
+// misspelt key even though exporters load non-strictly by default.
+func TestRun_CheckConfigRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  enable_webui: false\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	code, exit, _, stderr := run(t, &config.BaseConfig{}, "-check-config", "-config", path)
+	if !exit || code != ExitConfigError || !strings.Contains(stderr, `unknown field "enable_webui"`) {
+		t.Errorf("got exit=%v code=%d stderr=%q", exit, code, stderr)
+	}
+
+	if code, _, _, stderr := run(t, &config.BaseConfig{}, "-config", path); code != ExitOK {
+		t.Errorf("expected a normal load to ignore the key, got code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestRun_UnknownFlag(t *testing.T) {
+	if code, exit, _, _ := run(t, &config.BaseConfig{}, "-no-such-flag"); !exit || code != ExitUsage {
+		t.Errorf("want exit with code %d, got exit=%v code=%d", ExitUsage, exit, code)
+	}
+}
+
+// TestRun_ShowConfigRedactsSecrets asserts -show-config prints where values
+// came from without printing sensitive values.
+func TestRun_ShowConfigRedactsSecrets(t *testing.T) {
+	t.Setenv("SERVER_ADMIN_TOKEN", "s3cret")
+
+	cfg := &displayAdminConfig{}
+
+	code, exit, stdout, _ := run(t, cfg, "-show-config", "-set", "server.port=9100")
+	if !exit || code != ExitOK {
+		t.Fatalf("want exit with code %d, got exit=%v code=%d", ExitOK, exit, code)
+	}
+
+	if strings.Contains(stdout, "s3cret") {
+		t.Errorf("show-config leaked a secret: %q", stdout)
+	}
+
+	for _, want := range []string{"Admin Token: [REDACTED]", "\n  server:\n", "\n    port: 9100\n", "server.port: flag", "server.admin.token: env:SERVER_ADMIN_TOKEN"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output %q", want, stdout)
+		}
+	}
+}
+
+// TestRun_LoadsConfigFile asserts the config file is loaded and the exporter
+// is allowed to start.
+func TestRun_LoadsConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  port: 9200\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg := &config.BaseConfig{}
+
+	if code, exit, _, stderr := run(t, cfg, "-config", path); exit {
+		t.Fatalf("expected the exporter to start, got code=%d stderr=%q", code, stderr)
+	}
+
+	if cfg.Server.Port != 9200 {
+		t.Errorf("expected port 9200 from the file, got %d", cfg.Server.Port)
+	}
+}
+
+// displayAdminConfig includes the admin token in its display config
+type displayAdminConfig struct {
+	config.BaseConfig `yaml:",inline"`
+}
+
+func (c *displayAdminConfig) GetDisplayConfig() map[string]interface{} {
+	display := c.BaseConfig.GetDisplayConfig()
+	display["Admin Token"] = c.Server.Admin.Token
+
+	return display
+}
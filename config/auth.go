@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthConfig holds authentication settings for the HTTP server. When any
+// users or tokens are configured, every endpoint except the admin endpoints
+// (which have their own token) and, by default, /health and /ready requires
+// credentials.
+type AuthConfig struct {
+	BasicAuthUsers map[string]SensitiveString `yaml:"basic_auth_users"`        // Username to bcrypt password hash
+	BearerTokens   []SensitiveString          `yaml:"bearer_tokens"`           // Accepted bearer tokens
+	ExemptHealth   *bool                      `yaml:"exempt_health,omitempty"` // Leave /health and /ready unauthenticated (default: true)
+}
+
+// IsEnabled returns true if any users or tokens are configured
+func (a *AuthConfig) IsEnabled() bool {
+	return len(a.BasicAuthUsers) > 0 || len(a.BearerTokens) > 0
+}
+
+// IsHealthExempt returns true if /health and /ready are served without
+// authentication (defaults to true, so probes keep working)
+func (a *AuthConfig) IsHealthExempt() bool {
+	if a.ExemptHealth == nil {
+		return true
+	}
+
+	return *a.ExemptHealth
+}
+
+// Validate checks that every basic auth password is a bcrypt hash and that
+// no bearer token is empty, reporting every problem found
+func (a *AuthConfig) Validate() error {
+	var errs []error
+
+	for _, username := range slices.Sorted(maps.Keys(a.BasicAuthUsers)) {
+		hash := a.BasicAuthUsers[username]
+		if username == "" {
+			errs = append(errs, fmt.Errorf("basic_auth_users: username must not be empty"))
+			continue
+		}
+
+		if _, err := bcrypt.Cost([]byte(hash.Value())); err != nil {
+			errs = append(errs, fmt.Errorf("basic_auth_users: password for %q is not a bcrypt hash", username))
+		}
+	}
+
+	for i, token := range a.BearerTokens {
+		if token.IsEmpty() {
+			errs = append(errs, fmt.Errorf("bearer_tokens: token %d is empty", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	yaml "github.com/goccy/go-yaml"
@@ -11,20 +17,47 @@ import (
 // BaseConfig provides common configuration for all exporters
 type BaseConfig struct {
 	Server    ServerConfig    `yaml:"server"`
-	Logging   LoggingConfig   `yaml:"logging"`
+	Logging   LoggingConfig   `yaml:"logging" env:"LOG"`
 	Metrics   MetricsConfig   `yaml:"metrics"`
 	Tracing   TracingConfig   `yaml:"tracing"`
 	Profiling ProfilingConfig `yaml:"profiling"`
+	Probe     ProbeConfig     `yaml:"probe"`
+
+	// provenance records where each value came from when loaded by Loader
+	provenance map[string]string
+
+	// root is the config embedding this BaseConfig, as passed to Loader, so
+	// the display config can include the exporter's own settings
+	root interface{}
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Host         string `yaml:"host"`
-	Port         int    `yaml:"port"`
-	EnableWebUI  *bool  `yaml:"enable_web_ui,omitempty"` // Enable web UI (default: true)
-	EnableHealth *bool  `yaml:"enable_health,omitempty"` // Enable health endpoint (default: true)
+	Host            string      `yaml:"host"`
+	Port            int         `yaml:"port"`
+	EnableWebUI     *bool       `yaml:"enable_web_ui,omitempty"`    // Enable web UI (default: true)
+	EnableHealth    *bool       `yaml:"enable_health,omitempty"`    // Enable health endpoint (default: true)
+	EnableMetrics   *bool       `yaml:"enable_metrics,omitempty"`   // Enable /metrics endpoint (default: true)
+	ShutdownTimeout Duration    `yaml:"shutdown_timeout,omitempty"` // Maximum time to wait for a graceful shutdown (default: 30s)
+	Admin           AdminConfig `yaml:"admin"`
+	Auth            AuthConfig  `yaml:"auth"`
+	TLS             TLSConfig   `yaml:"tls"`
+	WebConfigFile   string      `yaml:"web_config_file,omitempty"` // Path to an exporter-toolkit web config file (replaces tls and auth.basic_auth_users)
+}
+
+// AdminConfig holds configuration for the admin endpoints
+type AdminConfig struct {
+	Token SensitiveString `yaml:"token"` // Bearer token for /admin endpoints (endpoints are disabled when empty)
 }
 
+// IsEnabled returns true if the admin endpoints are enabled
+func (a *AdminConfig) IsEnabled() bool {
+	return !a.Token.IsEmpty()
+}
+
+// DefaultShutdownTimeout is used when server.shutdown_timeout is not set
+const DefaultShutdownTimeout = 30 * time.Second
+
 // IsWebUIEnabled returns true if web UI is enabled (defaults to true)
 func (s *ServerConfig) IsWebUIEnabled() bool {
 	if s.EnableWebUI == nil {
@@ -43,6 +76,25 @@ func (s *ServerConfig) IsHealthEnabled() bool {
 	return *s.EnableHealth
 }
 
+// IsMetricsEnabled returns true if the /metrics endpoint is enabled
+// (defaults to true). It can be disabled when metrics are only pushed.
+func (s *ServerConfig) IsMetricsEnabled() bool {
+	if s.EnableMetrics == nil {
+		return true // default to enabled
+	}
+
+	return *s.EnableMetrics
+}
+
+// GetShutdownTimeout returns the graceful shutdown timeout (defaults to 30s)
+func (s *ServerConfig) GetShutdownTimeout() time.Duration {
+	if s.ShutdownTimeout.Duration <= 0 {
+		return DefaultShutdownTimeout
+	}
+
+	return s.ShutdownTimeout.Duration
+}
+
 // IsEnabled returns true if tracing is enabled (defaults to false)
 func (t *TracingConfig) IsEnabled() bool {
 	if t.Enabled == nil {
@@ -52,15 +104,157 @@ func (t *TracingConfig) IsEnabled() bool {
 	return *t.Enabled
 }
 
+// HeaderValues returns the OTLP headers with their actual values, for
+// passing to the exporter
+func (t *TracingConfig) HeaderValues() map[string]string {
+	return headerValues(t.Headers)
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level  string `yaml:"level"`
-	Format string `yaml:"format"` // "json" or "text"
+	Level  string `yaml:"level" enum:"debug,info,warn,error"`
+	Format string `yaml:"format" enum:"json,text"` // "json" or "text"
 }
 
 // MetricsConfig holds metrics configuration
 type MetricsConfig struct {
-	Collection CollectionConfig `yaml:"collection"`
+	Collection  CollectionConfig  `yaml:"collection" env:",inline"`
+	Namespace   string            `yaml:"namespace,omitempty"`       // Prefix added to every metric name
+	ConstLabels map[string]string `yaml:"const_labels,omitempty"`    // Labels added to every series, e.g. site: london
+	Relabel     []RelabelConfig   `yaml:"relabel,omitempty" env:"-"` // Rules to drop or rename labels before exposition
+	Cardinality CardinalityConfig `yaml:"cardinality"`
+	Push        PushConfig        `yaml:"push"`
+}
+
+// Push defaults, used when the push settings are not set
+const (
+	DefaultPushInterval   = time.Minute
+	DefaultPushTimeout    = 10 * time.Second
+	DefaultPushMaxRetries = 3
+)
+
+// PushConfig holds settings for pushing metrics to a Pushgateway or a
+// remote-write endpoint, alongside or instead of being scraped
+type PushConfig struct {
+	Interval    Duration          `yaml:"interval,omitempty"`    // Time between pushes (default: 1m)
+	Timeout     Duration          `yaml:"timeout,omitempty"`     // Timeout for each attempt (default: 10s)
+	MaxRetries  *int              `yaml:"max_retries,omitempty"` // Retries after a failed attempt (default: 3)
+	Pushgateway PushgatewayConfig `yaml:"pushgateway"`
+	RemoteWrite RemoteWriteConfig `yaml:"remote_write"`
+}
+
+// PushgatewayConfig configures pushing to a Prometheus Pushgateway
+type PushgatewayConfig struct {
+	URL      string                     `yaml:"url,omitempty"`      // Pushgateway URL; pushing is disabled when empty
+	Job      string                     `yaml:"job,omitempty"`      // Job label (default: the exporter name)
+	Grouping map[string]string          `yaml:"grouping,omitempty"` // Grouping labels, e.g. instance: batch-01
+	Username string                     `yaml:"username,omitempty"` // Basic auth username
+	Password SensitiveString            `yaml:"password,omitempty"` // Basic auth password
+	Headers  map[string]SensitiveString `yaml:"headers,omitempty"`  // Additional request headers
+}
+
+// RemoteWriteConfig configures pushing with the Prometheus remote-write
+// protocol
+type RemoteWriteConfig struct {
+	URL            string                     `yaml:"url,omitempty"`             // Remote-write URL; pushing is disabled when empty
+	Job            string                     `yaml:"job,omitempty"`             // Job label added to every series (default: the exporter name)
+	ExternalLabels map[string]string          `yaml:"external_labels,omitempty"` // Labels added to every series, e.g. cluster: prod-1
+	Username       string                     `yaml:"username,omitempty"`        // Basic auth username
+	Password       SensitiveString            `yaml:"password,omitempty"`        // Basic auth password
+	Headers        map[string]SensitiveString `yaml:"headers,omitempty"`         // Additional request headers
+}
+
+// IsEnabled returns true if a Pushgateway or remote-write URL is set
+func (p *PushConfig) IsEnabled() bool {
+	return p.Pushgateway.URL != "" || p.RemoteWrite.URL != ""
+}
+
+// GetInterval returns the time between pushes (defaults to 1m)
+func (p *PushConfig) GetInterval() time.Duration {
+	if p.Interval.Duration <= 0 {
+		return DefaultPushInterval
+	}
+
+	return p.Interval.Duration
+}
+
+// GetTimeout returns the timeout for each push attempt (defaults to 10s)
+func (p *PushConfig) GetTimeout() time.Duration {
+	if p.Timeout.Duration <= 0 {
+		return DefaultPushTimeout
+	}
+
+	return p.Timeout.Duration
+}
+
+// GetMaxRetries returns the retries after a failed push (defaults to 3)
+func (p *PushConfig) GetMaxRetries() int {
+	if p.MaxRetries == nil {
+		return DefaultPushMaxRetries
+	}
+
+	return *p.MaxRetries
+}
+
+// headerValues returns headers with their actual values
+func headerValues(headers map[string]SensitiveString) map[string]string {
+	values := make(map[string]string, len(headers))
+	for name, value := range headers {
+		values[name] = value.Value()
+	}
+
+	return values
+}
+
+// HeaderValues returns the Pushgateway headers with their actual values
+func (p *PushgatewayConfig) HeaderValues() map[string]string {
+	return headerValues(p.Headers)
+}
+
+// HeaderValues returns the remote-write headers with their actual values
+func (r *RemoteWriteConfig) HeaderValues() map[string]string {
+	return headerValues(r.Headers)
+}
+
+// CardinalityConfig limits how many series are exposed, so a collector that
+// labels with unbounded values can't overwhelm Prometheus. Zero means no
+// limit.
+type CardinalityConfig struct {
+	MaxSeries          int            `yaml:"max_series,omitempty"`            // Across all metrics
+	MaxSeriesPerMetric int            `yaml:"max_series_per_metric,omitempty"` // For each metric
+	MetricLimits       map[string]int `yaml:"metric_limits,omitempty"`         // By metric name, overriding max_series_per_metric
+}
+
+// labelNamePattern matches valid Prometheus label names, and so valid
+// metric namespaces
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// jobNamePattern matches push job names that are safe in Pushgateway URLs
+var jobNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// Relabel actions
+const (
+	RelabelDrop   = "drop"
+	RelabelRename = "rename"
+)
+
+// RelabelConfig drops or renames the labels whose names match Regex
+type RelabelConfig struct {
+	Action      string `yaml:"action" enum:"drop,rename"`
+	Regex       string `yaml:"regex"`                 // Matched against the whole label name
+	Replacement string `yaml:"replacement,omitempty"` // New name for rename; $1 refers to a capture group
+}
+
+// ProbeConfig holds the modules available on the /probe endpoint
+type ProbeConfig struct {
+	Modules map[string]ProbeModuleConfig `yaml:"modules,omitempty" env:"-"` // By name, selected with /probe?module=
+}
+
+// ProbeModuleConfig is a named set of settings for probing a target
+type ProbeModuleConfig struct {
+	Prober  string            `yaml:"prober"`            // Name of the prober the exporter registered
+	Timeout Duration          `yaml:"timeout,omitempty"` // Caps the probe below the scrape timeout
+	Params  map[string]string `yaml:"params,omitempty"`  // Settings for the prober
 }
 
 // CollectionConfig holds collection configuration
@@ -72,10 +266,10 @@ type CollectionConfig struct {
 
 // TracingConfig holds tracing configuration
 type TracingConfig struct {
-	Enabled     *bool             `yaml:"enabled,omitempty"` // Enable tracing (default: false)
-	ServiceName string            `yaml:"service_name"`      // Service name for traces
-	Endpoint    string            `yaml:"endpoint"`          // OTLP endpoint (default: "http://localhost:4318/v1/traces")
-	Headers     map[string]string `yaml:"headers"`           // Additional headers for OTLP
+	Enabled     *bool                      `yaml:"enabled,omitempty"` // Enable tracing (default: false)
+	ServiceName string                     `yaml:"service_name"`      // Service name for traces
+	Endpoint    string                     `yaml:"endpoint"`          // OTLP endpoint (default: "http://localhost:4318/v1/traces")
+	Headers     map[string]SensitiveString `yaml:"headers"`           // Additional headers for OTLP, often carrying credentials
 }
 
 // ProfilingConfig holds profiling configuration
@@ -101,7 +295,9 @@ func (c *CollectionConfig) UnmarshalYAML(unmarshal func(interface{}) error) erro
 		DefaultInterval Duration `yaml:"default_interval"`
 	}
 
-	var temp tempCollectionConfig
+	// Start from the current value so that a collection section without
+	// default_interval keeps any default already applied
+	temp := tempCollectionConfig{DefaultInterval: c.DefaultInterval}
 	if err := unmarshal(&temp); err != nil {
 		return err
 	}
@@ -122,16 +318,30 @@ func LoadConfig(path string, configFromEnv bool) (*BaseConfig, error) {
 	return Load(path)
 }
 
-// Load loads configuration from a YAML file
+// Load loads configuration from a YAML file. Keys that don't match a field,
+// such as an exporter's own sections, are ignored.
 func Load(path string) (*BaseConfig, error) {
+	return load(path, false)
+}
+
+// LoadStrict loads configuration from a YAML file like Load, but rejects
+// keys that don't match a field as likely typos. Use Loader with Strict to
+// check a config type that embeds BaseConfig.
+func LoadStrict(path string) (*BaseConfig, error) {
+	return load(path, true)
+}
+
+// load loads configuration from a YAML file, rejecting unknown keys in
+// strict mode
+func load(path string, strict bool) (*BaseConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var config BaseConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := decodeYAML(data, &config, strict); err != nil {
+		return nil, fmt.Errorf("failed to parse config file:\n%w", err)
 	}
 
 	// Set defaults
@@ -145,85 +355,30 @@ func Load(path string) (*BaseConfig, error) {
 	return &config, nil
 }
 
-// loadFromEnv loads configuration from environment variables
-func loadFromEnv() (*BaseConfig, error) {
-	config := &BaseConfig{}
-
-	// Server configuration
-	if host := os.Getenv("SERVER_HOST"); host != "" {
-		config.Server.Host = host
-	} else {
-		config.Server.Host = "0.0.0.0"
-	}
-
-	if portStr := os.Getenv("SERVER_PORT"); portStr != "" {
-		if port, err := parseInt(portStr); err != nil {
-			return nil, fmt.Errorf("invalid server port: %w", err)
-		} else {
-			config.Server.Port = port
-		}
-	} else {
-		config.Server.Port = 8080
+// decodeYAML decodes data into target. In strict mode unknown keys are
+// rejected. Errors show the offending line and column of the source.
+func decodeYAML(data []byte, target interface{}, strict bool) error {
+	var opts []yaml.DecodeOption
+	if strict {
+		opts = append(opts, yaml.Strict())
 	}
 
-	// Logging configuration
-	if level := os.Getenv("LOG_LEVEL"); level != "" {
-		config.Logging.Level = level
-	} else {
-		config.Logging.Level = "info"
+	if err := yaml.UnmarshalWithOptions(data, target, opts...); err != nil {
+		return errors.New(yaml.FormatError(err, false, true))
 	}
 
-	if format := os.Getenv("LOG_FORMAT"); format != "" {
-		config.Logging.Format = format
-	} else {
-		config.Logging.Format = "json"
-	}
-
-	// Metrics configuration
-	if intervalStr := os.Getenv("METRICS_DEFAULT_INTERVAL"); intervalStr != "" {
-		if interval, err := time.ParseDuration(intervalStr); err != nil {
-			return nil, fmt.Errorf("invalid metrics default interval: %w", err)
-		} else {
-			config.Metrics.Collection.DefaultInterval = Duration{interval}
-			config.Metrics.Collection.DefaultIntervalSet = true
-		}
-	} else {
-		config.Metrics.Collection.DefaultInterval = Duration{time.Second * 30}
-	}
-
-	// Tracing configuration
-	if enabledStr := os.Getenv("TRACING_ENABLED"); enabledStr != "" {
-		if enabled, err := parseBool(enabledStr); err != nil {
-			return nil, fmt.Errorf("invalid tracing enabled value: %w", err)
-		} else {
-			config.Tracing.Enabled = &enabled
-		}
-	}
-
-	if serviceName := os.Getenv("TRACING_SERVICE_NAME"); serviceName != "" {
-		config.Tracing.ServiceName = serviceName
-	}
-
-	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
-		config.Tracing.Endpoint = endpoint
-	}
+	return nil
+}
 
-	// Profiling configuration
-	if enabledStr := os.Getenv("PROFILING_ENABLED"); enabledStr != "" {
-		if enabled, err := parseBool(enabledStr); err != nil {
-			return nil, fmt.Errorf("invalid profiling enabled value: %w", err)
-		} else {
-			config.Profiling.Enabled = &enabled
-		}
-	}
+// loadFromEnv loads configuration from environment variables
+func loadFromEnv() (*BaseConfig, error) {
+	config := &BaseConfig{}
 
-	if serviceName := os.Getenv("PROFILING_SERVICE_NAME"); serviceName != "" {
-		config.Profiling.ServiceName = serviceName
+	if _, err := BindEnv(config, ""); err != nil {
+		return nil, fmt.Errorf("failed to load configuration from environment: %w", err)
 	}
 
-	if serverAddress := os.Getenv("PROFILING_SERVER_ADDRESS"); serverAddress != "" {
-		config.Profiling.ServerAddress = serverAddress
-	}
+	config.Metrics.Collection.DefaultIntervalSet = config.Metrics.Collection.DefaultInterval.Duration != 0
 
 	// Set defaults for any missing values
 	setDefaults(config)
@@ -246,6 +401,10 @@ func setDefaults(config *BaseConfig) {
 		config.Server.Port = 8080
 	}
 
+	if config.Server.ShutdownTimeout.Duration == 0 {
+		config.Server.ShutdownTimeout = Duration{DefaultShutdownTimeout}
+	}
+
 	// Set default values for new options (only if not explicitly set in YAML)
 	// Note: bool fields default to false, so we need to check if they were explicitly set
 	// For now, we'll assume they default to true unless explicitly set to false
@@ -272,24 +431,8 @@ func setDefaults(config *BaseConfig) {
 	}
 
 	if config.Tracing.Headers == nil {
-		config.Tracing.Headers = make(map[string]string)
-	}
-}
-
-// parseInt parses a string to int
-func parseInt(s string) (int, error) {
-	var i int
-
-	_, err := fmt.Sscanf(s, "%d", &i)
-	if err != nil {
-		return 0, err
-	}
-	// Check if there are any remaining characters (like decimal points)
-	if len(fmt.Sprintf("%d", i)) != len(s) {
-		return 0, fmt.Errorf("invalid integer format: %s", s)
+		config.Tracing.Headers = make(map[string]SensitiveString)
 	}
-
-	return i, nil
 }
 
 // parseBool parses a string to bool
@@ -304,29 +447,32 @@ func parseBool(s string) (bool, error) {
 	}
 }
 
-// Validate performs comprehensive validation of the configuration
+// Validate performs comprehensive validation of the configuration. Every
+// problem is reported, joined into a single error with one per line.
 func (c *BaseConfig) Validate() error {
-	// Validate server configuration
-	if err := c.validateServerConfig(); err != nil {
-		return fmt.Errorf("server config: %w", err)
+	var errs []error
+
+	for _, err := range c.validateServerConfig() {
+		errs = append(errs, fmt.Errorf("server config: %w", err))
 	}
 
-	// Validate logging configuration
-	if err := c.validateLoggingConfig(); err != nil {
-		return fmt.Errorf("logging config: %w", err)
+	for _, err := range c.validateLoggingConfig() {
+		errs = append(errs, fmt.Errorf("logging config: %w", err))
 	}
 
-	// Validate metrics configuration
-	if err := c.validateMetricsConfig(); err != nil {
-		return fmt.Errorf("metrics config: %w", err)
+	for _, err := range c.validateMetricsConfig() {
+		errs = append(errs, fmt.Errorf("metrics config: %w", err))
 	}
 
-	// Validate tracing configuration
-	if err := c.validateTracingConfig(); err != nil {
-		return fmt.Errorf("tracing config: %w", err)
+	for _, err := range c.validateTracingConfig() {
+		errs = append(errs, fmt.Errorf("tracing config: %w", err))
 	}
 
-	return nil
+	for _, err := range c.validateProbeConfig() {
+		errs = append(errs, fmt.Errorf("probe config: %w", err))
+	}
+
+	return errors.Join(errs...)
 }
 
 // GetDefaultInterval returns the default collection interval
@@ -334,28 +480,17 @@ func (c *BaseConfig) GetDefaultInterval() int {
 	return c.Metrics.Collection.DefaultInterval.Seconds()
 }
 
-// GetDisplayConfig returns configuration data safe for display
-// This method can be overridden by exporters to include their own configuration
+// GetDisplayConfig returns the configuration for display, built by
+// DisplayConfig. When the config was loaded by Loader this includes every
+// field of the exporter's config that embeds BaseConfig, so exporters only
+// need to override it to customise the output.
 func (c *BaseConfig) GetDisplayConfig() map[string]interface{} {
-	config := map[string]interface{}{
-		"Server Host":    c.Server.Host,
-		"Server Port":    c.Server.Port,
-		"Web UI Enabled": c.Server.IsWebUIEnabled(),
-		"Health Enabled": c.Server.IsHealthEnabled(),
-		"Log Level":      c.Logging.Level,
-		"Log Format":     c.Logging.Format,
-	}
-
-	// Add tracing info if enabled
-	if c.Tracing.IsEnabled() {
-		config["Tracing Enabled"] = true
-		config["Tracing Service Name"] = c.Tracing.ServiceName
-		config["Tracing Endpoint"] = c.Tracing.Endpoint
-	} else {
-		config["Tracing Enabled"] = false
+	// The root is ignored if this BaseConfig has since been copied out of it
+	if provider, ok := c.root.(baseConfigProvider); ok && provider.baseConfig() == c {
+		return DisplayConfig(c.root)
 	}
 
-	return config
+	return DisplayConfig(c)
 }
 
 // GetLogging returns the logging configuration
@@ -363,6 +498,11 @@ func (c *BaseConfig) GetLogging() *LoggingConfig {
 	return &c.Logging
 }
 
+// GetMetrics returns the metrics configuration
+func (c *BaseConfig) GetMetrics() *MetricsConfig {
+	return &c.Metrics
+}
+
 // GetServer returns the server configuration
 func (c *BaseConfig) GetServer() *ServerConfig {
 	return &c.Server
@@ -378,15 +518,39 @@ func (c *BaseConfig) GetTracing() *TracingConfig {
 	return &c.Tracing
 }
 
-func (c *BaseConfig) validateServerConfig() error {
+// GetProbe returns the probe configuration
+func (c *BaseConfig) GetProbe() *ProbeConfig {
+	return &c.Probe
+}
+
+func (c *BaseConfig) validateServerConfig() []error {
+	var errs []error
+
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
-		return fmt.Errorf("port must be between 1 and 65535, got %d", c.Server.Port)
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", c.Server.Port))
 	}
 
-	return nil
+	if c.Server.ShutdownTimeout.Duration < 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must not be negative, got %s", c.Server.ShutdownTimeout.Duration))
+	}
+
+	if c.Server.WebConfigFile != "" && c.Server.TLS.IsEnabled() {
+		errs = append(errs, fmt.Errorf("web_config_file and tls cannot both be set"))
+	}
+
+	if c.Server.WebConfigFile != "" && len(c.Server.Auth.BasicAuthUsers) > 0 {
+		errs = append(errs, fmt.Errorf("web_config_file and auth.basic_auth_users cannot both be set"))
+	}
+
+	errs = append(errs, prefixErrors("tls", c.Server.TLS.Validate())...)
+	errs = append(errs, prefixErrors("auth", c.Server.Auth.Validate())...)
+
+	return errs
 }
 
-func (c *BaseConfig) validateLoggingConfig() error {
+func (c *BaseConfig) validateLoggingConfig() []error {
+	var errs []error
+
 	validLevels := map[string]bool{
 		"debug": true,
 		"info":  true,
@@ -394,7 +558,7 @@ func (c *BaseConfig) validateLoggingConfig() error {
 		"error": true,
 	}
 	if !validLevels[c.Logging.Level] {
-		return fmt.Errorf("invalid logging level: %s", c.Logging.Level)
+		errs = append(errs, fmt.Errorf("invalid logging level: %s", c.Logging.Level))
 	}
 
 	validFormats := map[string]bool{
@@ -402,80 +566,204 @@ func (c *BaseConfig) validateLoggingConfig() error {
 		"text": true,
 	}
 	if !validFormats[c.Logging.Format] {
-		return fmt.Errorf("invalid logging format: %s", c.Logging.Format)
+		errs = append(errs, fmt.Errorf("invalid logging format: %s", c.Logging.Format))
 	}
 
-	return nil
+	return errs
 }
 
-func (c *BaseConfig) validateMetricsConfig() error {
+func (c *BaseConfig) validateMetricsConfig() []error {
+	var errs []error
+
 	if c.Metrics.Collection.DefaultInterval.Seconds() < 1 {
-		return fmt.Errorf("default interval must be at least 1 second, got %d", c.Metrics.Collection.DefaultInterval.Seconds())
+		errs = append(errs, fmt.Errorf("default interval must be at least 1 second, got %d", c.Metrics.Collection.DefaultInterval.Seconds()))
 	}
 
 	if c.Metrics.Collection.DefaultInterval.Seconds() > 86400 {
-		return fmt.Errorf("default interval must be at most 86400 seconds (24 hours), got %d", c.Metrics.Collection.DefaultInterval.Seconds())
+		errs = append(errs, fmt.Errorf("default interval must be at most 86400 seconds (24 hours), got %d", c.Metrics.Collection.DefaultInterval.Seconds()))
 	}
 
-	return nil
+	if c.Metrics.Namespace != "" && !labelNamePattern.MatchString(c.Metrics.Namespace) {
+		errs = append(errs, fmt.Errorf("invalid namespace %q", c.Metrics.Namespace))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Metrics.ConstLabels)) {
+		if !labelNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid const label name %q", name))
+		}
+	}
+
+	if c.Metrics.Cardinality.MaxSeries < 0 || c.Metrics.Cardinality.MaxSeriesPerMetric < 0 {
+		errs = append(errs, errors.New("cardinality limits must not be negative"))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Metrics.Cardinality.MetricLimits)) {
+		if c.Metrics.Cardinality.MetricLimits[name] < 0 {
+			errs = append(errs, fmt.Errorf("cardinality limit for %s must not be negative", name))
+		}
+	}
+
+	errs = append(errs, c.validatePushConfig()...)
+
+	// Rules by the literal label name they rename to
+	targets := make(map[string]int)
+
+	for i, rule := range c.Metrics.Relabel {
+		if rule.Action != RelabelDrop && rule.Action != RelabelRename {
+			errs = append(errs, fmt.Errorf("relabel[%d]: invalid action %q (must be %s or %s)", i, rule.Action, RelabelDrop, RelabelRename))
+		}
+
+		if _, err := regexp.Compile(rule.Regex); err != nil {
+			errs = append(errs, fmt.Errorf("relabel[%d]: invalid regex: %w", i, err))
+		}
+
+		if rule.Action == RelabelRename && rule.Replacement == "" {
+			errs = append(errs, fmt.Errorf("relabel[%d]: replacement is required for %s", i, RelabelRename))
+		}
+
+		// Replacements with capture group references are checked when applied
+		if rule.Action != RelabelRename || rule.Replacement == "" || strings.Contains(rule.Replacement, "$") {
+			continue
+		}
+
+		if !labelNamePattern.MatchString(rule.Replacement) {
+			errs = append(errs, fmt.Errorf("relabel[%d]: invalid replacement label name %q", i, rule.Replacement))
+		} else if previous, ok := targets[rule.Replacement]; ok {
+			errs = append(errs, fmt.Errorf("relabel[%d]: replacement %q is already the target of relabel[%d]", i, rule.Replacement, previous))
+		}
+
+		targets[rule.Replacement] = i
+	}
+
+	return errs
 }
 
-func (c *BaseConfig) validateTracingConfig() error {
+func (c *BaseConfig) validateTracingConfig() []error {
 	// Only validate if tracing is enabled
 	if !c.Tracing.IsEnabled() {
 		return nil
 	}
 
+	var errs []error
+
 	if c.Tracing.ServiceName == "" {
-		return fmt.Errorf("service name is required when tracing is enabled")
+		errs = append(errs, fmt.Errorf("service name is required when tracing is enabled"))
 	}
 
 	if c.Tracing.Endpoint == "" {
-		return fmt.Errorf("tracing endpoint must be configured when tracing is enabled")
+		errs = append(errs, fmt.Errorf("tracing endpoint must be configured when tracing is enabled"))
 	}
 
-	return nil
+	return errs
 }
 
-// ApplyGenericEnvVars applies generic (non-prefixed) environment variables to a BaseConfig.
-// This handles shared configuration like TRACING_ENABLED, PROFILING_ENABLED, etc.
-// that are common across all exporters and don't need exporter-specific prefixes.
-// This should be called by exporters after loading their own config to ensure
-// these generic environment variables are applied.
-func ApplyGenericEnvVars(config *BaseConfig) error {
-	// Tracing configuration (generic, no prefix)
-	if enabledStr := os.Getenv("TRACING_ENABLED"); enabledStr != "" {
-		if enabled, err := parseBool(enabledStr); err != nil {
-			return fmt.Errorf("invalid tracing enabled value: %w", err)
-		} else {
-			config.Tracing.Enabled = &enabled
+func (c *BaseConfig) validatePushConfig() []error {
+	push := &c.Metrics.Push
+
+	var errs []error
+
+	targets := []struct{ name, url string }{
+		{"pushgateway", push.Pushgateway.URL},
+		{"remote_write", push.RemoteWrite.URL},
+	}
+
+	for _, target := range targets {
+		if target.url == "" {
+			continue
+		}
+
+		if u, err := url.Parse(target.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("push: %s url must be an http or https URL, got %q", target.name, target.url))
 		}
 	}
 
-	if serviceName := os.Getenv("TRACING_SERVICE_NAME"); serviceName != "" {
-		config.Tracing.ServiceName = serviceName
+	if push.Interval.Duration < 0 || push.Timeout.Duration < 0 {
+		errs = append(errs, errors.New("push: interval and timeout must not be negative"))
 	}
 
-	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
-		config.Tracing.Endpoint = endpoint
+	if push.MaxRetries != nil && *push.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("push: max_retries must not be negative, got %d", *push.MaxRetries))
 	}
 
-	// Profiling configuration (generic, no prefix)
-	if enabledStr := os.Getenv("PROFILING_ENABLED"); enabledStr != "" {
-		if enabled, err := parseBool(enabledStr); err != nil {
-			return fmt.Errorf("invalid profiling enabled value: %w", err)
-		} else {
-			config.Profiling.Enabled = &enabled
+	for _, name := range slices.Sorted(maps.Keys(push.Pushgateway.Grouping)) {
+		if !labelNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("push: invalid pushgateway grouping label name %q", name))
 		}
 	}
 
-	if serviceName := os.Getenv("PROFILING_SERVICE_NAME"); serviceName != "" {
-		config.Profiling.ServiceName = serviceName
+	jobs := []struct{ name, job string }{
+		{"pushgateway", push.Pushgateway.Job},
+		{"remote_write", push.RemoteWrite.Job},
 	}
 
-	if serverAddress := os.Getenv("PROFILING_SERVER_ADDRESS"); serverAddress != "" {
-		config.Profiling.ServerAddress = serverAddress
+	for _, job := range jobs {
+		if job.job != "" && !jobNamePattern.MatchString(job.job) {
+			errs = append(errs, fmt.Errorf("push: %s job must only contain letters, digits, '_', '-' and '.', got %q", job.name, job.job))
+		}
 	}
 
-	return nil
+	for _, name := range slices.Sorted(maps.Keys(push.RemoteWrite.ExternalLabels)) {
+		switch {
+		case !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__"):
+			errs = append(errs, fmt.Errorf("push: invalid remote_write external label name %q", name))
+		case name == "job":
+			errs = append(errs, errors.New("push: set remote_write job instead of a job external label"))
+		}
+	}
+
+	return errs
+}
+
+func (c *BaseConfig) validateProbeConfig() []error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(c.Probe.Modules)) {
+		module := c.Probe.Modules[name]
+
+		if module.Prober == "" {
+			errs = append(errs, fmt.Errorf("module %s: prober is required", name))
+		}
+
+		if module.Timeout.Duration < 0 {
+			errs = append(errs, fmt.Errorf("module %s: timeout must not be negative", name))
+		}
+	}
+
+	return errs
+}
+
+// prefixErrors splits err into the errors it joins, if any, and adds prefix
+// to each so that every line of an aggregated error names its section
+func prefixErrors(prefix string, err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{fmt.Errorf("%s: %w", prefix, err)}
+	}
+
+	var errs []error
+	for _, inner := range joined.Unwrap() {
+		errs = append(errs, prefixErrors(prefix, inner)...)
+	}
+
+	return errs
+}
+
+// ApplyGenericEnvVars applies generic (non-prefixed) environment variables to a BaseConfig.
+// This handles shared configuration like TRACING_ENABLED, PROFILING_ENABLED, etc.
+// that are common across all exporters and don't need exporter-specific prefixes.
+// This should be called by exporters after loading their own config to ensure
+// these generic environment variables are applied.
+func ApplyGenericEnvVars(config *BaseConfig) error {
+	generic := struct {
+		Tracing   *TracingConfig   `yaml:"tracing"`
+		Profiling *ProfilingConfig `yaml:"profiling"`
+	}{&config.Tracing, &config.Profiling}
+
+	_, err := BindEnv(&generic, "")
+
+	return err
 }
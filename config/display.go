@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// displayDefault is shown for optional settings that were left unset, such
+// as *bool fields whose default is applied by an IsEnabled-style method
+const displayDefault = "(default)"
+
+// DisplayConfig returns the configuration in target, a struct or pointer to
+// a struct such as one embedding BaseConfig, for display in the web UI and
+// -show-config.
+//
+// Nested structs and string-keyed maps become nested
+// map[string]interface{} sections. Fields are named by their
+// `display:"Label"` tag, or else their yaml name, and `display:"-"` hides a
+// field. Values implementing SensitiveValue are kept as they are, so they
+// are shown redacted and the server can mark them as sensitive.
+func DisplayConfig(target interface{}) map[string]interface{} {
+	display := make(map[string]interface{})
+
+	v := reflect.ValueOf(target)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return display
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Struct {
+		addDisplayFields(v, display)
+	}
+
+	return display
+}
+
+// addDisplayFields adds each field of struct v to display, merging inlined
+// structs into the same section
+func addDisplayFields(v reflect.Value, display map[string]interface{}) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := yamlFieldName(field)
+
+		label := field.Tag.Get("display")
+		if skip || label == "-" {
+			continue
+		}
+
+		fieldValue := v.Field(i)
+
+		if inline {
+			for fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+
+			if fieldValue.Kind() == reflect.Struct {
+				addDisplayFields(fieldValue, display)
+			}
+
+			continue
+		}
+
+		if label == "" {
+			label = name
+		}
+
+		if value, ok := displayValue(fieldValue); ok {
+			display[label] = value
+		}
+	}
+}
+
+// displayValue returns the value to display for v, or false if there is
+// nothing to show, such as an empty section
+func displayValue(v reflect.Value) (interface{}, bool) {
+	if _, ok := v.Interface().(SensitiveValue); ok {
+		return v.Interface(), true
+	}
+
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return displayDefault, true
+		}
+
+		return displayValue(v.Elem())
+	}
+
+	// Types such as Duration and time.Time display as their string form
+	if _, ok := v.Interface().(fmt.Stringer); ok {
+		return v.Interface(), true
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		section := make(map[string]interface{})
+		addDisplayFields(v, section)
+
+		return section, len(section) > 0
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface(), true
+		}
+
+		section := make(map[string]interface{}, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			if value, ok := displayValue(iter.Value()); ok {
+				section[iter.Key().String()] = value
+			}
+		}
+
+		return section, len(section) > 0
+	default:
+		return v.Interface(), true
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGetDisplayConfig_IncludesExporterFields asserts a config loaded by
+// Loader displays the exporter's own fields as nested sections, without any
+// GetDisplayConfig override.
+func TestGetDisplayConfig_IncludesExporterFields(t *testing.T) {
+	type exporterConfig struct {
+		BaseConfig `yaml:",inline"`
+		Upstream   struct {
+			URL      string          `yaml:"url" display:"Upstream URL"`
+			Password SensitiveString `yaml:"password"`
+			Timeout  Duration        `yaml:"timeout"`
+			Internal string          `yaml:"internal" display:"-"`
+		} `yaml:"upstream"`
+	}
+
+	cfg := &exporterConfig{}
+	cfg.Upstream.URL = "http://example.com"
+	cfg.Upstream.Password = NewSensitiveString("s3cret")
+	cfg.Upstream.Timeout = Duration{10 * time.Second}
+	cfg.Upstream.Internal = "hidden"
+
+	if err := (&Loader{}).Load(cfg); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	display := cfg.GetDisplayConfig()
+
+	upstream, ok := display["upstream"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected an upstream section, got %#v", display)
+	}
+
+	if upstream["Upstream URL"] != "http://example.com" {
+		t.Errorf("expected the display label to be used, got %v", upstream)
+	}
+
+	if _, ok := upstream["password"].(SensitiveValue); !ok {
+		t.Errorf("expected the password to stay a SensitiveValue, got %T", upstream["password"])
+	}
+
+	if _, ok := upstream["internal"]; ok {
+		t.Error(`expected display:"-" to hide the field`)
+	}
+
+	if timeout, ok := upstream["timeout"].(Duration); !ok || timeout.String() != "10s" {
+		t.Errorf("expected the timeout to display as 10s, got %v", upstream["timeout"])
+	}
+
+	server, ok := display["server"].(map[string]interface{})
+	if !ok || server["port"] != 8080 || server["enable_web_ui"] != displayDefault {
+		t.Errorf("unexpected server section: %v", display["server"])
+	}
+}
+
+// TestGetDisplayConfig_BaseConfigOnly asserts a BaseConfig that wasn't
+// loaded by Loader displays its own fields.
+func TestGetDisplayConfig_BaseConfigOnly(t *testing.T) {
+	cfg := &BaseConfig{}
+	cfg.Logging.Level = "debug"
+
+	logging, ok := cfg.GetDisplayConfig()["logging"].(map[string]interface{})
+	if !ok || logging["level"] != "debug" {
+		t.Errorf("unexpected logging section: %v", cfg.GetDisplayConfig()["logging"])
+	}
+}
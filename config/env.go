@@ -0,0 +1,356 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(Duration{})
+	timeDurationType    = reflect.TypeOf(time.Duration(0))
+	sensitiveStringType = reflect.TypeOf(SensitiveString{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindEnv sets the fields of target, a pointer to a struct such as one
+// embedding BaseConfig, from environment variables and returns the names of
+// the variables that were applied.
+//
+// Variable names are built by joining the names of the nested fields with
+// "_". A field's name comes from its env tag, falling back to its yaml tag
+// in upper case, so `Server ServerConfig yaml:"server"` containing
+// `Port int yaml:"port"` is SERVER_PORT. The tag `env:"-"` skips a field and
+// `env:",inline"` (like embedding) adds no name segment of its own.
+//
+// When prefix is set (e.g. "MYEXP_"), each variable is looked up with the
+// prefix first and then without it, so shared variables such as
+// TRACING_ENABLED keep working for every exporter.
+//
+// Supported types are strings, booleans, numbers, Duration, time.Duration,
+// SensitiveString, encoding.TextUnmarshaler implementations, pointers to
+// any of these, comma-separated slices and comma-separated key=value maps.
+func BindEnv(target interface{}, prefix string) ([]string, error) {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("BindEnv requires a pointer to a struct, got %T", target)
+	}
+
+	b := bindEnv(v.Elem(), prefix)
+
+	return b.applied, errors.Join(b.errs...)
+}
+
+// envBinder holds the state of a single BindEnv call
+type envBinder struct {
+	prefix  string
+	applied []string
+	errs    []error
+
+	// sources maps the YAML path of each field that was set to the name of
+	// the variable that set it
+	sources map[string]string
+}
+
+// bindEnv binds the struct v and returns the binder with its results
+func bindEnv(v reflect.Value, prefix string) *envBinder {
+	b := &envBinder{
+		prefix:  strings.TrimSuffix(prefix, "_"),
+		sources: make(map[string]string),
+	}
+	b.bindStruct(v, nil, nil)
+
+	return b
+}
+
+// bindStruct binds each exported field of v. envPath and yamlPath hold the
+// name segments of the enclosing fields.
+func (b *envBinder) bindStruct(v reflect.Value, envPath, yamlPath []string) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := envFieldName(field)
+		if skip {
+			continue
+		}
+
+		fieldEnvPath := envPath
+		if !inline {
+			fieldEnvPath = appendPath(envPath, name)
+		}
+
+		fieldYAMLPath := yamlPath
+		if yamlName, yamlInline, _ := yamlFieldName(field); !yamlInline {
+			fieldYAMLPath = appendPath(yamlPath, yamlName)
+		}
+
+		b.bindField(v.Field(i), fieldEnvPath, fieldYAMLPath)
+	}
+}
+
+// bindField binds a single field, recursing into nested structs
+func (b *envBinder) bindField(v reflect.Value, envPath, yamlPath []string) {
+	if isNestedStruct(v.Type()) {
+		b.bindStruct(v, envPath, yamlPath)
+		return
+	}
+
+	if v.Kind() == reflect.Pointer && isNestedStruct(v.Type().Elem()) {
+		if !v.IsNil() {
+			b.bindStruct(v.Elem(), envPath, yamlPath)
+			return
+		}
+
+		// Only allocate the struct if one of its fields was set
+		elem := reflect.New(v.Type().Elem())
+		before := len(b.applied)
+		b.bindStruct(elem.Elem(), envPath, yamlPath)
+
+		if len(b.applied) > before {
+			v.Set(elem)
+		}
+
+		return
+	}
+
+	if len(envPath) == 0 {
+		return
+	}
+
+	name, value, ok := b.lookup(strings.Join(envPath, "_"))
+	if !ok {
+		return
+	}
+
+	if err := setFromString(v, value); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid value for %s: %w", name, err))
+		return
+	}
+
+	b.applied = append(b.applied, name)
+	b.sources[strings.Join(yamlPath, ".")] = name
+}
+
+// lookup finds the variable for name, trying the prefixed name first
+func (b *envBinder) lookup(name string) (string, string, bool) {
+	if b.prefix != "" {
+		prefixed := b.prefix + "_" + name
+		if value, ok := os.LookupEnv(prefixed); ok && value != "" {
+			return prefixed, value, true
+		}
+	}
+
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return name, value, true
+	}
+
+	return "", "", false
+}
+
+// envFieldName returns the name segment for a field and whether the field
+// is inlined into its parent or skipped
+func envFieldName(field reflect.StructField) (name string, inline bool, skip bool) {
+	if tag, ok := field.Tag.Lookup("env"); ok {
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			return "", false, true
+		}
+
+		if opts == "inline" {
+			return "", true, false
+		}
+
+		return name, false, false
+	}
+
+	yamlName, inline, skip := yamlFieldName(field)
+
+	return strings.ToUpper(yamlName), inline, skip
+}
+
+// yamlFieldName returns the YAML key for a field and whether the field is
+// inlined into its parent or skipped
+func yamlFieldName(field reflect.StructField) (name string, inline bool, skip bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "", false, true
+	}
+
+	if field.Anonymous || strings.Contains(opts, "inline") {
+		return "", true, false
+	}
+
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name, false, false
+}
+
+// appendPath returns a copy of path with name appended
+func appendPath(path []string, name string) []string {
+	return append(append([]string(nil), path...), name)
+}
+
+// isNestedStruct returns true for structs that are bound field by field
+// rather than parsed from a single value
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	if t == durationType || t == sensitiveStringType {
+		return false
+	}
+
+	return !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setFromString parses value into v according to v's type
+func setFromString(v reflect.Value, value string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := parseEnvDuration(value)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(Duration{d}))
+
+		return nil
+	case timeDurationType:
+		d, err := parseEnvDuration(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	case sensitiveStringType:
+		parsed, err := ParseSensitiveString(value)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(parsed))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		parsed, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(parsed)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), value); err != nil {
+			return err
+		}
+
+		v.Set(elem)
+	case reflect.Slice:
+		parts := splitList(value)
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+
+		for i, part := range parts {
+			if err := setFromString(slice.Index(i), part); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+
+		for _, part := range splitList(value) {
+			key, val, ok := strings.Cut(part, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", part)
+			}
+
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := setFromString(k, strings.TrimSpace(key)); err != nil {
+				return err
+			}
+
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := setFromString(e, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(k, e)
+		}
+
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// parseEnvDuration parses a duration such as "30s", treating a plain integer
+// as seconds like the YAML form does
+func parseEnvDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// splitList splits a comma-separated list, trimming spaces and dropping
+// empty entries
+func splitList(value string) []string {
+	var parts []string
+
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
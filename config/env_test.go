@@ -0,0 +1,108 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBindEnv_NestedAndPrefixed asserts BindEnv derives variable names from
+// struct tags, parses the supported types, prefers prefixed variables and
+// reports what it applied.
+func TestBindEnv_NestedAndPrefixed(t *testing.T) {
+	type exporterConfig struct {
+		BaseConfig `yaml:",inline"`
+		Upstream   struct {
+			URL     string            `yaml:"url"`
+			Timeout Duration          `yaml:"timeout"`
+			Targets []string          `yaml:"targets"`
+			Labels  map[string]string `yaml:"labels"`
+			Retries int               `env:"MAX_RETRIES"`
+			Ignored string            `yaml:"-"`
+		} `yaml:"upstream"`
+	}
+
+	t.Setenv("SERVER_PORT", "9000")
+	t.Setenv("MYEXP_SERVER_PORT", "9100")
+	t.Setenv("SERVER_ENABLE_WEB_UI", "false")
+	t.Setenv("SERVER_ADMIN_TOKEN", "s3cret")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("METRICS_DEFAULT_INTERVAL", "45s")
+	t.Setenv("TRACING_HEADERS", "x-api-key=abc=,x-tenant=ops")
+	t.Setenv("UPSTREAM_URL", "http://example.com")
+	t.Setenv("UPSTREAM_TIMEOUT", "10")
+	t.Setenv("UPSTREAM_TARGETS", "a, b,,c")
+	t.Setenv("UPSTREAM_LABELS", "env=prod")
+	t.Setenv("MYEXP_UPSTREAM_MAX_RETRIES", "3")
+	t.Setenv("UPSTREAM_IGNORED", "nope")
+
+	var cfg exporterConfig
+
+	applied, err := BindEnv(&cfg, "MYEXP_")
+	if err != nil {
+		t.Fatalf("BindEnv: %v", err)
+	}
+
+	if cfg.Server.Port != 9100 {
+		t.Errorf("expected the prefixed port to win, got %d", cfg.Server.Port)
+	}
+
+	if cfg.Server.IsWebUIEnabled() {
+		t.Error("expected the web UI to be disabled")
+	}
+
+	if cfg.Server.Admin.Token.Value() != "s3cret" || cfg.Logging.Level != "debug" {
+		t.Errorf("unexpected admin token or log level: %v %q", cfg.Server.Admin.Token, cfg.Logging.Level)
+	}
+
+	if cfg.Metrics.Collection.DefaultInterval.Duration != 45*time.Second {
+		t.Errorf("expected a 45s default interval, got %s", cfg.Metrics.Collection.DefaultInterval.Duration)
+	}
+
+	if cfg.Tracing.Headers["x-api-key"].Value() != "abc=" || cfg.Tracing.Headers["x-tenant"].Value() != "ops" {
+		t.Errorf("unexpected tracing headers: %v", cfg.Tracing.Headers)
+	}
+
+	if cfg.Upstream.URL != "http://example.com" || cfg.Upstream.Timeout.Duration != 10*time.Second {
+		t.Errorf("unexpected upstream: %+v", cfg.Upstream)
+	}
+
+	if !slices.Equal(cfg.Upstream.Targets, []string{"a", "b", "c"}) || cfg.Upstream.Labels["env"] != "prod" {
+		t.Errorf("unexpected targets or labels: %v %v", cfg.Upstream.Targets, cfg.Upstream.Labels)
+	}
+
+	if cfg.Upstream.Retries != 3 || cfg.Upstream.Ignored != "" {
+		t.Errorf("unexpected retries or ignored field: %d %q", cfg.Upstream.Retries, cfg.Upstream.Ignored)
+	}
+
+	for _, name := range []string{"MYEXP_SERVER_PORT", "UPSTREAM_TARGETS", "MYEXP_UPSTREAM_MAX_RETRIES"} {
+		if !slices.Contains(applied, name) {
+			t.Errorf("expected %s in applied variables %v", name, applied)
+		}
+	}
+
+	if slices.Contains(applied, "SERVER_PORT") {
+		t.Error("the unprefixed port should not be reported when the prefixed one was used")
+	}
+}
+
+// TestBindEnv_ReportsAllInvalidValues asserts every invalid variable is
+// reported rather than only the first.
+func TestBindEnv_ReportsAllInvalidValues(t *testing.T) {
+	t.Setenv("SERVER_PORT", "eighty")
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "soon")
+
+	var cfg BaseConfig
+
+	_, err := BindEnv(&cfg, "")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, name := range []string{"SERVER_PORT", "SERVER_SHUTDOWN_TIMEOUT"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s in error %q", name, err)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sources recorded in a configuration's provenance. Values set from a file
+// or an environment variable are recorded as "file:<path>" or "env:<NAME>".
+const (
+	SourceDefault = "default"
+	SourceFlag    = "flag"
+)
+
+// Loader builds a configuration from several layers. Each layer overrides
+// the ones before it:
+//
+//  1. defaults: the values already in the target, then BaseConfig's defaults
+//  2. YAML files, in order
+//  3. environment variables (see BindEnv)
+//  4. overrides, typically from repeated -set path=value flags
+//
+// The source of every final value is recorded and available from
+// BaseConfig.Provenance.
+type Loader struct {
+	Files     []string // YAML files; later files override earlier ones
+	EnvPrefix string   // Optional exporter prefix for environment variables, e.g. "MYEXP_"
+	Overrides []string // "path=value" pairs using YAML paths, e.g. "server.port=9100"
+
+	// Strict rejects keys in files that don't match a field as likely
+	// typos, instead of ignoring them
+	Strict bool
+}
+
+// baseConfigProvider is implemented by BaseConfig and any struct embedding it
+type baseConfigProvider interface {
+	baseConfig() *BaseConfig
+}
+
+func (c *BaseConfig) baseConfig() *BaseConfig {
+	return c
+}
+
+// Provenance returns where each configuration value came from, keyed by its
+// YAML path (e.g. "server.port"). It is only populated by Loader.
+func (c *BaseConfig) Provenance() map[string]string {
+	return c.provenance
+}
+
+// Load populates target, a pointer to a struct embedding BaseConfig, from
+// each layer in turn and validates the result
+func (l *Loader) Load(target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Load requires a pointer to a struct, got %T", target)
+	}
+
+	var base *BaseConfig
+	if provider, ok := target.(baseConfigProvider); ok {
+		base = provider.baseConfig()
+		setDefaults(base)
+	}
+
+	provenance := make(map[string]string)
+	snapshot := flattenConfig(v.Elem())
+
+	for path := range snapshot {
+		provenance[path] = SourceDefault
+	}
+
+	for _, file := range l.Files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		if err := decodeYAML(data, target, l.Strict); err != nil {
+			return fmt.Errorf("failed to parse config file %s:\n%w", file, err)
+		}
+
+		snapshot = recordChanges(provenance, snapshot, flattenConfig(v.Elem()), "file:"+file)
+	}
+
+	binder := bindEnv(v.Elem(), l.EnvPrefix)
+	if len(binder.errs) > 0 {
+		return fmt.Errorf("failed to load configuration from environment: %w", errors.Join(binder.errs...))
+	}
+
+	for path, name := range binder.sources {
+		provenance[path] = "env:" + name
+	}
+
+	for _, override := range l.Overrides {
+		path, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return fmt.Errorf("invalid override %q, expected path=value", override)
+		}
+
+		if err := setPath(v.Elem(), strings.Split(path, "."), value); err != nil {
+			return fmt.Errorf("invalid override %s: %w", path, err)
+		}
+
+		provenance[path] = SourceFlag
+	}
+
+	if base != nil {
+		// Fill anything a layer cleared, keeping an interval set by any layer
+		snapshot = flattenConfig(v.Elem())
+		base.Metrics.Collection.DefaultIntervalSet = base.Metrics.Collection.DefaultInterval.Duration != 0
+		setDefaults(base)
+		recordChanges(provenance, snapshot, flattenConfig(v.Elem()), SourceDefault)
+
+		base.provenance = provenance
+		base.root = target
+	}
+
+	if validator, ok := target.(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("configuration validation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FormatProvenance returns the provenance as sorted "path: source" lines,
+// omitting values that were left at their defaults
+func FormatProvenance(provenance map[string]string) []string {
+	lines := make([]string, 0, len(provenance))
+
+	for path, source := range provenance {
+		if source != SourceDefault {
+			lines = append(lines, path+": "+source)
+		}
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
+
+// FlagList is a flag.Value collecting every occurrence of a repeated flag,
+// such as -config or -set
+type FlagList []string
+
+// String implements flag.Value
+func (f *FlagList) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set implements flag.Value
+func (f *FlagList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// recordChanges sets source as the provenance of every path whose value
+// differs between before and after, and returns after
+func recordChanges(provenance, before, after map[string]string, source string) map[string]string {
+	for path, value := range after {
+		if previous, ok := before[path]; !ok || previous != value {
+			provenance[path] = source
+		}
+	}
+
+	return after
+}
+
+// flattenConfig returns a comparable representation of every leaf value in
+// v keyed by YAML path. The representations include sensitive values and
+// are only used to detect changes, never displayed.
+func flattenConfig(v reflect.Value) map[string]string {
+	values := make(map[string]string)
+	flattenStruct(v, nil, values)
+
+	return values
+}
+
+func flattenStruct(v reflect.Value, path []string, values map[string]string) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := yamlFieldName(field)
+		if skip {
+			continue
+		}
+
+		fieldPath := path
+		if !inline {
+			fieldPath = appendPath(path, name)
+		}
+
+		fv := v.Field(i)
+
+		switch {
+		case isNestedStruct(fv.Type()):
+			flattenStruct(fv, fieldPath, values)
+		case fv.Kind() == reflect.Pointer && isNestedStruct(fv.Type().Elem()):
+			if !fv.IsNil() {
+				flattenStruct(fv.Elem(), fieldPath, values)
+			}
+		default:
+			values[strings.Join(fieldPath, ".")] = snapshotValue(fv)
+		}
+	}
+}
+
+// snapshotValue returns a comparable representation of a leaf value. Unlike
+// %#v it includes the configured form of sensitive values, and it follows
+// pointers rather than printing their addresses.
+func snapshotValue(v reflect.Value) string {
+	switch {
+	case v.Type() == sensitiveStringType:
+		return strconv.Quote(v.Interface().(SensitiveString).reference())
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return "nil"
+		}
+
+		return snapshotValue(v.Elem())
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = snapshotValue(v.Index(i))
+		}
+
+		return "[" + strings.Join(parts, ",") + "]"
+	case v.Kind() == reflect.Map:
+		parts := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			parts = append(parts, snapshotValue(key)+":"+snapshotValue(v.MapIndex(key)))
+		}
+
+		sort.Strings(parts)
+
+		return "{" + strings.Join(parts, ",") + "}"
+	default:
+		return fmt.Sprintf("%#v", v.Interface())
+	}
+}
+
+// setPath sets the field at the YAML path to value, looking through inlined
+// structs and allocating nil struct pointers on the way
+func setPath(v reflect.Value, path []string, value string) error {
+	field, ok := findField(v, path[0])
+	if !ok {
+		return fmt.Errorf("unknown field %q", path[0])
+	}
+
+	if field.Kind() == reflect.Pointer && isNestedStruct(field.Type().Elem()) {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+
+		field = field.Elem()
+	}
+
+	if len(path) == 1 {
+		if isNestedStruct(field.Type()) {
+			return fmt.Errorf("%q is a section, not a value", path[0])
+		}
+
+		return setFromString(field, value)
+	}
+
+	if !isNestedStruct(field.Type()) {
+		return fmt.Errorf("%q has no field %q", path[0], path[1])
+	}
+
+	return setPath(field, path[1:], value)
+}
+
+// findField returns the field of struct v with the given YAML key,
+// searching inlined structs too
+func findField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := yamlFieldName(field)
+		if skip {
+			continue
+		}
+
+		if inline && isNestedStruct(field.Type) {
+			if found, ok := findField(v.Field(i), key); ok {
+				return found, true
+			}
+
+			continue
+		}
+
+		if name == key {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoader_LayersAndProvenance asserts later layers override earlier ones
+// and that the source of each value is recorded.
+func TestLoader_LayersAndProvenance(t *testing.T) {
+	type exporterConfig struct {
+		BaseConfig `yaml:",inline"`
+		Upstream   struct {
+			URL     string `yaml:"url"`
+			Retries int    `yaml:"retries"`
+		} `yaml:"upstream"`
+	}
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	local := filepath.Join(dir, "local.yaml")
+
+	writeFile(t, base, "server:\n  port: 9000\nlogging:\n  level: warn\nupstream:\n  url: http://base\n")
+	writeFile(t, local, "server:\n  port: 9001\n")
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("MYEXP_UPSTREAM_RETRIES", "5")
+
+	cfg := &exporterConfig{}
+	cfg.Upstream.Retries = 1
+
+	loader := &Loader{
+		Files:     []string{base, local},
+		EnvPrefix: "MYEXP_",
+		Overrides: []string{"upstream.url=http://flag"},
+	}
+	if err := loader.Load(cfg); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Server.Port != 9001 || cfg.Logging.Level != "debug" || cfg.Upstream.URL != "http://flag" || cfg.Upstream.Retries != 5 {
+		t.Fatalf("unexpected config: port=%d level=%s url=%s retries=%d",
+			cfg.Server.Port, cfg.Logging.Level, cfg.Upstream.URL, cfg.Upstream.Retries)
+	}
+
+	if cfg.Metrics.Collection.DefaultInterval.Duration != 30*time.Second {
+		t.Errorf("expected the default interval, got %s", cfg.Metrics.Collection.DefaultInterval.Duration)
+	}
+
+	want := map[string]string{
+		"server.port":                         "file:" + local,
+		"logging.level":                       "env:LOG_LEVEL",
+		"upstream.url":                        SourceFlag,
+		"upstream.retries":                    "env:MYEXP_UPSTREAM_RETRIES",
+		"server.host":                         SourceDefault,
+		"metrics.collection.default_interval": SourceDefault,
+	}
+
+	provenance := cfg.Provenance()
+	for path, source := range want {
+		if provenance[path] != source {
+			t.Errorf("%s: want source %q, got %q", path, source, provenance[path])
+		}
+	}
+}
+
+// TestLoader_RejectsUnknownOverride asserts a typo in an override path is an
+// error rather than being ignored.
+func TestLoader_RejectsUnknownOverride(t *testing.T) {
+	loader := &Loader{Overrides: []string{"server.prot=9100"}}
+	if err := loader.Load(&BaseConfig{}); err == nil {
+		t.Fatal("expected an error for an unknown override path")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// TestLoader_RejectsUnknownFields asserts typos in files are reported with
+// their position in strict mode, and ignored otherwise.
+func TestLoader_RejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "server:\n  enable_webui: false\n")
+
+	err := (&Loader{Files: []string{path}, Strict: true}).Load(&BaseConfig{})
+	if err == nil || !strings.Contains(err.Error(), `[2:3] unknown field "enable_webui"`) {
+		t.Errorf("expected an unknown field error with its position, got %v", err)
+	}
+
+	if err := (&Loader{Files: []string{path}}).Load(&BaseConfig{}); err != nil {
+		t.Errorf("expected unknown fields to be ignored by default, got %v", err)
+	}
+}
+
+// TestLoad_StrictIsOptIn asserts Load still accepts files with exporter
+// specific sections, while LoadStrict rejects them.
+func TestLoad_StrictIsOptIn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "server:\n  port: 9100\nmyexp:\n  target: localhost\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("expected Load to ignore unknown sections, got %v", err)
+	}
+
+	if cfg.Server.Port != 9100 {
+		t.Errorf("expected port 9100, got %d", cfg.Server.Port)
+	}
+
+	if _, err := LoadStrict(path); err == nil || !strings.Contains(err.Error(), `unknown field "myexp"`) {
+		t.Errorf("expected LoadStrict to reject the unknown section, got %v", err)
+	}
+}
+
+// TestBaseConfig_ValidateReportsAllErrors asserts validation doesn't stop at
+// the first problem.
+func TestBaseConfig_ValidateReportsAllErrors(t *testing.T) {
+	cfg := &BaseConfig{}
+	cfg.Server.Port = 70000
+	cfg.Logging.Level = "loud"
+	cfg.Logging.Format = "xml"
+	cfg.Server.TLS = TLSConfig{CertFile: "tls.crt", MinVersion: "TLS13", MaxVersion: "TLS12"}
+	cfg.Metrics.ConstLabels = map[string]string{"site-name": "london"}
+	cfg.Metrics.Relabel = []RelabelConfig{
+		{Action: "keep", Regex: "pod"},
+		{Action: RelabelRename, Regex: "pod", Replacement: "pod-name"},
+		{Action: RelabelRename, Regex: "host", Replacement: "instance"},
+		{Action: RelabelRename, Regex: "node", Replacement: "instance"},
+	}
+	cfg.Metrics.Cardinality.MaxSeries = -1
+	cfg.Probe.Modules = map[string]ProbeModuleConfig{"ping": {}}
+	cfg.Metrics.Push.RemoteWrite.URL = "localhost:9090"
+	cfg.Metrics.Push.Pushgateway.Job = "nightly backup"
+	cfg.Metrics.Push.RemoteWrite.ExternalLabels = map[string]string{"job": "backup"}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, want := range []string{
+		"server config: port must be between",
+		"server config: tls: key_file is required",
+		"server config: tls: min_version TLS13 is greater",
+		"logging config: invalid logging level",
+		"logging config: invalid logging format",
+		`metrics config: invalid const label name "site-name"`,
+		`metrics config: relabel[0]: invalid action "keep"`,
+		`metrics config: relabel[1]: invalid replacement label name "pod-name"`,
+		`metrics config: relabel[3]: replacement "instance" is already the target of relabel[2]`,
+		"metrics config: cardinality limits must not be negative",
+		`metrics config: push: remote_write url must be an http or https URL, got "localhost:9090"`,
+		`metrics config: push: pushgateway job must only contain letters, digits, '_', '-' and '.', got "nightly backup"`,
+		"metrics config: push: set remote_write job instead of a job external label",
+		"probe config: module ping: prober is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// durationPattern matches the duration strings accepted by time.ParseDuration
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the YAML
+// configuration file for target, a struct or pointer to a struct such as
+// one embedding BaseConfig. Unknown keys are disallowed, matching strict
+// decoding. Fields can carry `description:"..."` and `enum:"a,b"` tags to
+// enrich the schema.
+func JSONSchema(target interface{}) ([]byte, error) {
+	t := reflect.TypeOf(target)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("JSONSchema requires a struct, got %T", target)
+	}
+
+	schema := schemaForType(t)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaForType returns the schema for values of type t
+func schemaForType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType, timeDurationType:
+		return map[string]interface{}{
+			"description": `A duration such as "30s" or "5m", or an integer number of seconds`,
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "string", "pattern": durationPattern},
+				map[string]interface{}{"type": "integer", "minimum": 0},
+			},
+		}
+	case sensitiveStringType:
+		return map[string]interface{}{"type": "string"}
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		addProperties(t, properties)
+
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// addProperties adds the schema of each field of struct t to properties,
+// merging inlined structs into the same object
+func addProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := yamlFieldName(field)
+		if skip {
+			continue
+		}
+
+		if inline {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+
+			if fieldType.Kind() == reflect.Struct {
+				addProperties(fieldType, properties)
+			}
+
+			continue
+		}
+
+		schema := schemaForType(field.Type)
+
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+
+		if enum := field.Tag.Get("enum"); enum != "" {
+			schema["enum"] = strings.Split(enum, ",")
+		}
+
+		properties[name] = schema
+	}
+}
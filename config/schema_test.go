@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestJSONSchema_DescribesExporterConfig asserts the schema covers inlined
+// BaseConfig sections and exporter fields, and disallows unknown keys.
+func TestJSONSchema_DescribesExporterConfig(t *testing.T) {
+	type exporterConfig struct {
+		BaseConfig `yaml:",inline"`
+		Upstream   struct {
+			URL     string   `yaml:"url" description:"Upstream base URL"`
+			Targets []string `yaml:"targets"`
+			Timeout Duration `yaml:"timeout"`
+		} `yaml:"upstream"`
+	}
+
+	data, err := JSONSchema(&exporterConfig{})
+	if err != nil {
+		t.Fatalf("JSONSchema: %v", err)
+	}
+
+	var schema struct {
+		AdditionalProperties bool `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type                 string                     `json:"type"`
+			AdditionalProperties bool                       `json:"additionalProperties"`
+			Properties           map[string]json.RawMessage `json:"properties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+
+	if schema.AdditionalProperties {
+		t.Error("unknown top-level keys should be disallowed")
+	}
+
+	for _, section := range []string{"server", "logging", "metrics", "tracing", "profiling", "upstream"} {
+		if schema.Properties[section].Type != "object" {
+			t.Errorf("expected an object schema for %s", section)
+		}
+	}
+
+	server := schema.Properties["server"]
+	if _, ok := server.Properties["enable_web_ui"]; !ok || server.AdditionalProperties {
+		t.Errorf("unexpected server schema: %+v", server)
+	}
+
+	var level struct {
+		Enum []string `json:"enum"`
+	}
+	if err := json.Unmarshal(schema.Properties["logging"].Properties["level"], &level); err != nil || len(level.Enum) != 4 {
+		t.Errorf("expected the logging level enum, got %v (%v)", level.Enum, err)
+	}
+
+	var url struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(schema.Properties["upstream"].Properties["url"], &url); err != nil || url.Description == "" {
+		t.Errorf("expected the url description, got %q (%v)", url.Description, err)
+	}
+}
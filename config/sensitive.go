@@ -2,6 +2,19 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefixes for SensitiveString values that refer to a secret stored
+// elsewhere rather than containing it
+const (
+	secretFilePrefix = "file://"
+	secretEnvPrefix  = "env:"
 )
 
 // SensitiveValue interface for types that can identify themselves as sensitive
@@ -10,9 +23,15 @@ type SensitiveValue interface {
 }
 
 // SensitiveString represents a string value that should be treated as sensitive
-// and redacted when displayed in configuration or logs
+// and redacted when displayed in configuration or logs.
+//
+// When set from configuration, the value can refer to a secret instead of
+// containing it: "file:///run/secrets/api_token" reads the file (and re-reads
+// it when it changes, so rotated secrets are picked up without a restart) and
+// "env:API_TOKEN" reads the environment variable.
 type SensitiveString struct {
-	value string
+	value  string
+	source *secretSource
 }
 
 // NewSensitiveString creates a new SensitiveString with the given value
@@ -20,22 +39,78 @@ func NewSensitiveString(value string) SensitiveString {
 	return SensitiveString{value: value}
 }
 
+// ParseSensitiveString creates a SensitiveString from a configuration value,
+// resolving file:// and env: references. It fails if the referenced secret
+// can't be read.
+func ParseSensitiveString(value string) (SensitiveString, error) {
+	var source *secretSource
+
+	switch {
+	case strings.HasPrefix(value, secretFilePrefix):
+		source = &secretSource{path: strings.TrimPrefix(value, secretFilePrefix)}
+	case strings.HasPrefix(value, secretEnvPrefix):
+		source = &secretSource{envVar: strings.TrimPrefix(value, secretEnvPrefix)}
+	default:
+		return NewSensitiveString(value), nil
+	}
+
+	if err := source.load(); err != nil {
+		return SensitiveString{}, err
+	}
+
+	return SensitiveString{value: value, source: source}, nil
+}
+
 // String returns a redacted representation of the sensitive string
 func (s SensitiveString) String() string {
-	if s.value == "" {
+	if s.IsEmpty() {
 		return "[EMPTY]"
 	}
 
 	return "[REDACTED]"
 }
 
-// Value returns the actual sensitive value (use with caution)
+// GoString keeps the value redacted when formatted with %#v
+func (s SensitiveString) GoString() string {
+	return fmt.Sprintf("config.SensitiveString(%q)", s.String())
+}
+
+// LogValue keeps the value redacted in slog output
+func (s SensitiveString) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// Value returns the actual sensitive value (use with caution). For file
+// references the file is re-read if its modification time or size has
+// changed since it was last read; if that fails the last value is kept.
 func (s SensitiveString) Value() string {
+	if s.source != nil {
+		return s.source.current()
+	}
+
 	return s.value
 }
 
-// IsEmpty returns true if the sensitive string is empty
+// Refresh re-reads a file or env reference now, returning an error and
+// keeping the previous value if the secret can't be read. Copies of the
+// SensitiveString see the new value too. Literal values are left as they
+// are.
+func (s SensitiveString) Refresh() error {
+	if s.source == nil {
+		return nil
+	}
+
+	return s.source.load()
+}
+
+// IsEmpty returns true if the sensitive string is empty. For references it
+// checks the value as last read, without touching the file, so redacting a
+// value never does I/O.
 func (s SensitiveString) IsEmpty() bool {
+	if s.source != nil {
+		return s.source.cached() == ""
+	}
+
 	return s.value == ""
 }
 
@@ -49,6 +124,12 @@ func (s SensitiveString) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s.String())
 }
 
+// MarshalYAML keeps the value redacted when the configuration is written
+// out as YAML
+func (s SensitiveString) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface
 func (s *SensitiveString) UnmarshalJSON(data []byte) error {
 	var str string
@@ -56,7 +137,132 @@ func (s *SensitiveString) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	s.value = str
+	parsed, err := ParseSensitiveString(str)
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
+
+// UnmarshalYAML implements custom unmarshaling so sensitive values can be
+// set from YAML configuration files
+func (s *SensitiveString) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+
+	parsed, err := ParseSensitiveString(str)
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
+
+// reference returns the configured form of the value: the reference for
+// file:// and env: values, otherwise the value itself. It is used to detect
+// configuration changes and must never be displayed.
+func (s SensitiveString) reference() string {
+	return s.value
+}
+
+// secretSource reads a secret from a file or environment variable. Copies
+// of a SensitiveString share their source, so a re-read file is seen by all.
+type secretSource struct {
+	path   string
+	envVar string
+
+	mu      sync.Mutex
+	value   string
+	modTime time.Time
+	size    int64
+	lastErr string
+}
+
+// load reads the secret, failing if it is missing. The previous value is
+// kept on failure.
+func (s *secretSource) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.envVar != "" {
+		value, ok := os.LookupEnv(s.envVar)
+		if !ok {
+			return fmt.Errorf("secret environment variable %s is not set", s.envVar)
+		}
+
+		s.value = value
+
+		return nil
+	}
+
+	return s.readFile()
+}
+
+// current returns the secret, re-reading the file if it has changed. If the
+// file can no longer be read the last value is kept.
+func (s *secretSource) current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.envVar != "" {
+		return s.value
+	}
+
+	info, err := os.Stat(s.path)
+	if err == nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.value
+	}
+
+	if err == nil {
+		err = s.readFile()
+	} else {
+		err = fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	// Warn once per distinct error rather than on every use
+	if err != nil && err.Error() != s.lastErr {
+		slog.Warn("Failed to re-read secret file, keeping the previous value", "path", s.path, "error", err)
+	}
+
+	s.lastErr = ""
+	if err != nil {
+		s.lastErr = err.Error()
+	}
+
+	return s.value
+}
+
+// cached returns the secret as last read
+func (s *secretSource) cached() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.value
+}
+
+// readFile reads the secret file, trimming a trailing newline, and records
+// its modification time and size. Callers must hold mu.
+func (s *secretSource) readFile() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	s.value = strings.TrimRight(string(data), "\r\n")
+	s.modTime = info.ModTime()
+	s.size = info.Size()
 
 	return nil
 }
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+// TestSensitiveString_FileReferenceReread asserts a file:// secret is re-read
+// when the file changes and keeps its last value when the file goes away.
+func TestSensitiveString_FileReferenceReread(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_token")
+	writeFile(t, path, "first\n")
+
+	var cfg struct {
+		Token SensitiveString `yaml:"token"`
+	}
+	if err := yaml.Unmarshal([]byte("token: file://"+path+"\n"), &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if got := cfg.Token.Value(); got != "first" {
+		t.Fatalf("expected the file contents without the newline, got %q", got)
+	}
+
+	// Same size as before, so move the mtime on to be sure the change is seen
+	writeFile(t, path, "secon\n")
+
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	copied := cfg.Token
+	if got := copied.Value(); got != "secon" {
+		t.Errorf("expected the rotated secret, got %q", got)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if got := cfg.Token.Value(); got != "secon" || cfg.Token.IsEmpty() {
+		t.Errorf("expected the last value to be kept when the file is missing, got %q", got)
+	}
+
+	if err := cfg.Token.Refresh(); err == nil {
+		t.Error("expected an error refreshing a missing secret file")
+	}
+}
+
+func TestParseSensitiveString_References(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "from-env")
+
+	secret, err := ParseSensitiveString("env:TEST_API_TOKEN")
+	if err != nil || secret.Value() != "from-env" {
+		t.Errorf("env reference: got %q, %v", secret.Value(), err)
+	}
+
+	if _, err := ParseSensitiveString("env:TEST_UNSET_TOKEN"); err == nil {
+		t.Error("expected an error for an unset environment variable")
+	}
+
+	if _, err := ParseSensitiveString("file:///does/not/exist"); err == nil {
+		t.Error("expected an error for a missing secret file")
+	}
+
+	if secret, _ := ParseSensitiveString("plain"); secret.Value() != "plain" {
+		t.Errorf("expected a literal value, got %q", secret.Value())
+	}
+}
+
+// TestSensitiveString_Redacted asserts the value doesn't leak through
+// formatting, slog or marshalling.
+func TestSensitiveString_Redacted(t *testing.T) {
+	secret := NewSensitiveString("hunter2")
+
+	var logs bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	logger.Info("test", "token", secret)
+
+	out, err := yaml.Marshal(map[string]SensitiveString{"token": secret})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	for name, formatted := range map[string]string{
+		"%v":   fmt.Sprintf("%v", secret),
+		"%#v":  fmt.Sprintf("%#v", secret),
+		"%+v":  fmt.Sprintf("%+v", struct{ Token SensitiveString }{secret}),
+		"slog": logs.String(),
+		"yaml": string(out),
+	} {
+		if strings.Contains(formatted, "hunter2") || !strings.Contains(formatted, "[REDACTED]") {
+			t.Errorf("%s: expected a redacted value, got %q", name, formatted)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TLSConfig holds TLS settings for the HTTP server. The fields mirror the
+// tls_server_config section of the Prometheus exporter-toolkit web config
+// file, so the same settings can be used in either place.
+type TLSConfig struct {
+	CertFile         string   `yaml:"cert_file"`         // Server certificate (TLS is enabled when set)
+	KeyFile          string   `yaml:"key_file"`          // Server private key
+	ClientCAFile     string   `yaml:"client_ca_file"`    // CA used to verify client certificates
+	ClientAuthType   string   `yaml:"client_auth_type"`  // e.g. "RequireAndVerifyClientCert" (default: "NoClientCert")
+	MinVersion       string   `yaml:"min_version"`       // "TLS10" to "TLS13" (default: "TLS12")
+	MaxVersion       string   `yaml:"max_version"`       // "TLS10" to "TLS13" (default: "TLS13")
+	CipherSuites     []string `yaml:"cipher_suites"`     // Go cipher suite names (default: Go's defaults)
+	CurvePreferences []string `yaml:"curve_preferences"` // e.g. "X25519", "CurveP256"
+}
+
+// WebConfig is the subset of the Prometheus exporter-toolkit web config file
+// understood by promexporter. Other settings, such as http_server_config or
+// inline certificates, are rejected rather than ignored.
+type WebConfig struct {
+	TLSServerConfig TLSConfig                  `yaml:"tls_server_config"`
+	BasicAuthUsers  map[string]SensitiveString `yaml:"basic_auth_users"` // Username to bcrypt password hash
+}
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                           tls.NoClientCert,
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+var curves = map[string]tls.CurveID{
+	"CurveP256":      tls.CurveP256,
+	"CurveP384":      tls.CurveP384,
+	"CurveP521":      tls.CurveP521,
+	"X25519":         tls.X25519,
+	"X25519MLKEM768": tls.X25519MLKEM768,
+}
+
+// LoadWebConfig loads an exporter-toolkit web config file. Relative file
+// paths inside it are resolved against the file's directory, matching the
+// exporter-toolkit behaviour.
+func LoadWebConfig(path string) (*WebConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read web config file: %w", err)
+	}
+
+	// Ignoring a setting such as http_server_config could silently weaken
+	// the server, so unknown keys are an error
+	var webConfig WebConfig
+	if err := decodeYAML(data, &webConfig, true); err != nil {
+		return nil, fmt.Errorf("failed to parse web config file, only tls_server_config file paths and basic_auth_users are supported:\n%w", err)
+	}
+
+	dir := filepath.Dir(path)
+	tlsConfig := &webConfig.TLSServerConfig
+
+	for _, file := range []*string{&tlsConfig.CertFile, &tlsConfig.KeyFile, &tlsConfig.ClientCAFile} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(dir, *file)
+		}
+	}
+
+	auth := AuthConfig{BasicAuthUsers: webConfig.BasicAuthUsers}
+	if err := errors.Join(tlsConfig.Validate(), auth.Validate()); err != nil {
+		return nil, fmt.Errorf("web config file: %w", err)
+	}
+
+	return &webConfig, nil
+}
+
+// ResolveTLS returns the effective TLS settings: those from the web config
+// file when one is configured, otherwise server.tls
+func (s *ServerConfig) ResolveTLS() (*TLSConfig, error) {
+	if s.WebConfigFile == "" {
+		return &s.TLS, nil
+	}
+
+	webConfig, err := LoadWebConfig(s.WebConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webConfig.TLSServerConfig, nil
+}
+
+// ResolveAuth returns the effective authentication settings: server.auth,
+// with the basic auth users from the web config file when one is configured
+func (s *ServerConfig) ResolveAuth() (*AuthConfig, error) {
+	if s.WebConfigFile == "" {
+		return &s.Auth, nil
+	}
+
+	webConfig, err := LoadWebConfig(s.WebConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := s.Auth
+	if len(webConfig.BasicAuthUsers) > 0 {
+		auth.BasicAuthUsers = webConfig.BasicAuthUsers
+	}
+
+	return &auth, nil
+}
+
+// IsEnabled returns true if TLS is configured
+func (t *TLSConfig) IsEnabled() bool {
+	return t.CertFile != ""
+}
+
+// Validate checks that the TLS settings are consistent and use known names,
+// reporting every problem found
+func (t *TLSConfig) Validate() error {
+	if !t.IsEnabled() {
+		if t.KeyFile != "" || t.ClientCAFile != "" {
+			return fmt.Errorf("cert_file is required when key_file or client_ca_file is set")
+		}
+
+		return nil
+	}
+
+	var errs []error
+
+	if t.KeyFile == "" {
+		errs = append(errs, fmt.Errorf("key_file is required when cert_file is set"))
+	}
+
+	clientAuth, err := t.ClientAuth()
+	if err != nil {
+		errs = append(errs, err)
+	} else if clientAuth >= tls.VerifyClientCertIfGiven && t.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("client_ca_file is required for client_auth_type %s", t.ClientAuthType))
+	}
+
+	minVersion, minErr := t.MinTLSVersion()
+	maxVersion, maxErr := t.MaxTLSVersion()
+
+	switch {
+	case minErr != nil || maxErr != nil:
+		errs = append(errs, minErr, maxErr)
+	case minVersion > maxVersion:
+		errs = append(errs, fmt.Errorf("min_version %s is greater than max_version %s", t.MinVersion, t.MaxVersion))
+	}
+
+	if _, err := t.CipherSuiteIDs(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := t.CurveIDs(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ClientAuth returns the client authentication policy. When a client CA is
+// configured without an explicit policy, client certificates are required
+// and verified.
+func (t *TLSConfig) ClientAuth() (tls.ClientAuthType, error) {
+	if t.ClientAuthType == "" && t.ClientCAFile != "" {
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	clientAuth, ok := clientAuthTypes[t.ClientAuthType]
+	if !ok {
+		return tls.NoClientCert, fmt.Errorf("invalid client_auth_type: %s", t.ClientAuthType)
+	}
+
+	return clientAuth, nil
+}
+
+// MinTLSVersion returns the minimum TLS version (defaults to TLS 1.2)
+func (t *TLSConfig) MinTLSVersion() (uint16, error) {
+	return parseTLSVersion(t.MinVersion, tls.VersionTLS12)
+}
+
+// MaxTLSVersion returns the maximum TLS version (defaults to TLS 1.3)
+func (t *TLSConfig) MaxTLSVersion() (uint16, error) {
+	return parseTLSVersion(t.MaxVersion, tls.VersionTLS13)
+}
+
+// CipherSuiteIDs returns the configured cipher suites, or nil to use Go's
+// defaults
+func (t *TLSConfig) CipherSuiteIDs() ([]uint16, error) {
+	if len(t.CipherSuites) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(t.CipherSuites))
+
+	for _, name := range t.CipherSuites {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite: %s", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// CurveIDs returns the configured curve preferences, or nil to use Go's
+// defaults
+func (t *TLSConfig) CurveIDs() ([]tls.CurveID, error) {
+	if len(t.CurvePreferences) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]tls.CurveID, 0, len(t.CurvePreferences))
+
+	for _, name := range t.CurvePreferences {
+		id, ok := curves[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve: %s", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// parseTLSVersion converts a version name such as "TLS12" to its constant
+func parseTLSVersion(name string, fallback uint16) (uint16, error) {
+	if name == "" {
+		return fallback, nil
+	}
+
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid TLS version: %s", name)
+	}
+
+	return version, nil
+}
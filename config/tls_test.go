@@ -0,0 +1,66 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestLoadWebConfig_BasicAuthUsers asserts a web config file with only
+// basic_auth_users is loaded and its users replace server.auth's.
+func TestLoadWebConfig_BasicAuthUsers(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "web.yml")
+	writeFile(t, path, "basic_auth_users:\n  prometheus: "+string(hash)+"\n")
+
+	serverConfig := ServerConfig{
+		WebConfigFile: path,
+		Auth:          AuthConfig{BearerTokens: []SensitiveString{NewSensitiveString("scrape-token")}},
+	}
+
+	auth, err := serverConfig.ResolveAuth()
+	if err != nil {
+		t.Fatalf("ResolveAuth: %v", err)
+	}
+
+	if got := auth.BasicAuthUsers["prometheus"].Value(); got != string(hash) {
+		t.Errorf("expected the user from the web config file, got %q", got)
+	}
+
+	if len(auth.BearerTokens) != 1 {
+		t.Errorf("expected server.auth bearer tokens to be kept, got %d", len(auth.BearerTokens))
+	}
+
+	if serverConfig.Auth.BasicAuthUsers != nil {
+		t.Error("expected server.auth to be left unchanged")
+	}
+
+	writeFile(t, path, "basic_auth_users:\n  prometheus: plaintext\n")
+
+	if _, err := LoadWebConfig(path); err == nil || !strings.Contains(err.Error(), "not a bcrypt hash") {
+		t.Errorf("expected a bcrypt error, got %v", err)
+	}
+}
+
+// TestLoadWebConfig_RejectsUnsupportedSettings asserts exporter-toolkit
+// settings promexporter doesn't implement are an error rather than ignored.
+func TestLoadWebConfig_RejectsUnsupportedSettings(t *testing.T) {
+	for name, content := range map[string]string{
+		"http_server_config": "http_server_config:\n  http2: false\n",
+		"inline cert":        "tls_server_config:\n  cert: \"-----BEGIN CERTIFICATE-----\"\n",
+	} {
+		path := filepath.Join(t.TempDir(), "web.yml")
+		writeFile(t, path, content)
+
+		_, err := LoadWebConfig(path)
+		if err == nil || !strings.Contains(err.Error(), "supported") {
+			t.Errorf("%s: expected an unsupported setting error, got %v", name, err)
+		}
+	}
+}
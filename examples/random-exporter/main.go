@@ -2,28 +2,27 @@ package main
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"log/slog"
 	"math/rand"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/d0ugal/promexporter/app"
+	"github.com/d0ugal/promexporter/cli"
 	"github.com/d0ugal/promexporter/config"
 	"github.com/d0ugal/promexporter/logging"
 	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
 	"github.com/d0ugal/promexporter/tracing"
+	"github.com/d0ugal/promexporter/version"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
 )
 
 // RandomExporterConfig extends the base configuration
 type RandomExporterConfig struct {
-	config.BaseConfig
-	Random RandomConfig `yaml:"random"`
+	config.BaseConfig `yaml:",inline"`
+	Random            RandomConfig `yaml:"random"`
 }
 
 type RandomConfig struct {
@@ -32,12 +31,12 @@ type RandomConfig struct {
 	// Number of random metrics to generate
 	MetricCount int `yaml:"metric_count"`
 	// Enable random errors for testing error tracing
-	EnableRandomErrors bool `yaml:"enable_random_errors"`
+	EnableRandomErrors bool `yaml:"enable_random_errors" env:"ENABLE_ERRORS"`
 	// Error probability (0.0 to 1.0)
 	ErrorProbability float64 `yaml:"error_probability"`
 }
 
-// RandomCollector implements the Collector interface
+// RandomCollector implements the ScheduledCollector interface
 type RandomCollector struct {
 	config  *RandomExporterConfig
 	metrics *RandomMetrics
@@ -67,110 +66,103 @@ type RandomMetrics struct {
 	RandomInfo *prometheus.GaugeVec
 }
 
-// NewRandomMetrics creates a new metrics registry
-func NewRandomMetrics(registry *promexporter_metrics.Registry) *RandomMetrics {
-	return &RandomMetrics{
-		// Counter metrics
-		RandomCounter: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "random_counter_total",
-				Help: "A random counter that increments over time",
-			},
-			[]string{"service", "region"},
-		),
-		RandomCounterRate: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "random_counter_rate_total",
-				Help: "A random counter with varying increment rates",
-			},
-			[]string{"service", "rate_type"},
-		),
-
-		// Gauge metrics
-		RandomGauge: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "random_gauge",
-				Help: "A random gauge with fluctuating values",
-			},
-			[]string{"instance", "type"},
-		),
-		RandomTemperature: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "random_temperature_celsius",
-				Help: "Simulated temperature readings",
-			},
-			[]string{"sensor", "location"},
-		),
-		RandomMemory: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "random_memory_usage_bytes",
-				Help: "Simulated memory usage",
-			},
-			[]string{"process", "type"},
-		),
-
-		// Histogram metrics
-		RandomLatency: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "random_latency_seconds",
-				Help:    "Simulated request latency",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "endpoint"},
-		),
-		RandomResponseTime: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "random_response_time_seconds",
-				Help:    "Simulated response times",
-				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
-			},
-			[]string{"method", "status"},
-		),
-
-		// Summary metrics
-		RandomDuration: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name:       "random_duration_seconds",
-				Help:       "Simulated operation duration",
-				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
-			},
-			[]string{"operation", "priority"},
-		),
-		RandomProcessingTime: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name:       "random_processing_time_seconds",
-				Help:       "Simulated processing times",
-				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
-			},
-			[]string{"task", "worker"},
-		),
-
-		// Info metrics
-		RandomInfo: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "random_info",
-				Help: "Information about the random exporter",
-			},
-			[]string{"version", "build_date", "go_version"},
-		),
+// NewRandomMetrics creates the random metrics, registering each one with
+// the registry so it is also listed in the web UI
+func NewRandomMetrics(registry *promexporter_metrics.Registry) (*RandomMetrics, error) {
+	rm := &RandomMetrics{}
+
+	var err error
+
+	// Counter metrics
+	rm.RandomCounter, err = registry.NewCounterVec(prometheus.CounterOpts{
+		Name: "random_counter_total",
+		Help: "A random counter that increments over time",
+	}, []string{"service", "region"})
+	if err != nil {
+		return nil, err
 	}
-}
 
-// RegisterMetrics registers all metrics with the registry
-func (rm *RandomMetrics) RegisterMetrics(registry *promexporter_metrics.Registry) {
-	promRegistry := registry.GetRegistry()
-	promRegistry.MustRegister(
-		rm.RandomCounter,
-		rm.RandomCounterRate,
-		rm.RandomGauge,
-		rm.RandomTemperature,
-		rm.RandomMemory,
-		rm.RandomLatency,
-		rm.RandomResponseTime,
-		rm.RandomDuration,
-		rm.RandomProcessingTime,
-		rm.RandomInfo,
-	)
+	rm.RandomCounterRate, err = registry.NewCounterVec(prometheus.CounterOpts{
+		Name: "random_counter_rate_total",
+		Help: "A random counter with varying increment rates",
+	}, []string{"service", "rate_type"})
+	if err != nil {
+		return nil, err
+	}
+
+	// Gauge metrics
+	rm.RandomGauge, err = registry.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "random_gauge",
+		Help: "A random gauge with fluctuating values",
+	}, []string{"instance", "type"})
+	if err != nil {
+		return nil, err
+	}
+
+	rm.RandomTemperature, err = registry.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "random_temperature_celsius",
+		Help: "Simulated temperature readings",
+	}, []string{"sensor", "location"})
+	if err != nil {
+		return nil, err
+	}
+
+	rm.RandomMemory, err = registry.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "random_memory_usage_bytes",
+		Help: "Simulated memory usage",
+	}, []string{"process", "type"})
+	if err != nil {
+		return nil, err
+	}
+
+	// Histogram metrics
+	rm.RandomLatency, err = registry.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "random_latency_seconds",
+		Help:    "Simulated request latency",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "endpoint"})
+	if err != nil {
+		return nil, err
+	}
+
+	rm.RandomResponseTime, err = registry.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "random_response_time_seconds",
+		Help:    "Simulated response times",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "status"})
+	if err != nil {
+		return nil, err
+	}
+
+	// Summary metrics
+	rm.RandomDuration, err = registry.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "random_duration_seconds",
+		Help:       "Simulated operation duration",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	}, []string{"operation", "priority"})
+	if err != nil {
+		return nil, err
+	}
+
+	rm.RandomProcessingTime, err = registry.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "random_processing_time_seconds",
+		Help:       "Simulated processing times",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	}, []string{"task", "worker"})
+	if err != nil {
+		return nil, err
+	}
+
+	// Info metrics
+	rm.RandomInfo, err = registry.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "random_info",
+		Help: "Information about the random exporter",
+	}, []string{"version", "build_date", "go_version"})
+	if err != nil {
+		return nil, err
+	}
+
+	return rm, nil
 }
 
 // NewRandomCollector creates a new random collector
@@ -182,101 +174,47 @@ func NewRandomCollector(cfg *RandomExporterConfig, metrics *RandomMetrics, app *
 	}
 }
 
-// Start implements the Collector interface
-func (rc *RandomCollector) Start(ctx context.Context) {
-	// Start the collection loop
-	go func() {
-		ticker := time.NewTicker(rc.config.Random.CollectionInterval.Duration)
-		defer ticker.Stop()
-
-		slog.Info("Starting metric collection loop",
-			"interval", rc.config.Random.CollectionInterval.Duration,
-			"metric_count", rc.config.Random.MetricCount,
-			"enable_errors", rc.config.Random.EnableRandomErrors,
-			"error_probability", rc.config.Random.ErrorProbability,
-		)
-
-		for {
-			select {
-			case <-ctx.Done():
-				slog.Info("Stopping metric collection loop")
-				return
-			case <-ticker.C:
-				if err := rc.Collect(ctx); err != nil {
-					slog.Error("Failed to collect metrics", "error", err)
-				}
-			}
-		}
-	}()
+// Name implements the ScheduledCollector interface
+func (rc *RandomCollector) Name() string {
+	return "random-collector"
 }
 
-// Stop implements the Collector interface
-func (rc *RandomCollector) Stop() {
-	slog.Info("Stopping random collector")
+// Interval implements the ScheduledCollector interface
+func (rc *RandomCollector) Interval() time.Duration {
+	return rc.config.Random.CollectionInterval.Duration
 }
 
-// Collect implements the Collector interface
+// Collect implements the ScheduledCollector interface. The scheduler already
+// wraps each run in a span, so ctx carries it and the generate* sub-spans
+// are nested under it.
 func (rc *RandomCollector) Collect(ctx context.Context) error {
-	// Get tracer for this collection cycle
-	tracer := rc.app.GetTracer()
-	var collectorSpan *tracing.CollectorSpan
-	var spanCtx context.Context
-
-	if tracer != nil && tracer.IsEnabled() {
-		collectorSpan = tracer.NewCollectorSpan(ctx, "random-collector", "collect-metrics")
-		spanCtx = collectorSpan.Context()
-		defer collectorSpan.End()
-	} else {
-		spanCtx = ctx
-	}
-
-	// Add event for collection start
-	if collectorSpan != nil {
-		collectorSpan.AddEvent("collection_started",
-			attribute.String("metric_count", fmt.Sprintf("%d", rc.config.Random.MetricCount)))
-	}
-
 	// Check for random errors
 	if rc.config.Random.EnableRandomErrors && rand.Float64() < rc.config.Random.ErrorProbability {
-		err := fmt.Errorf("random error occurred during collection")
-		if collectorSpan != nil {
-			collectorSpan.RecordError(err, attribute.String("error_type", "random"))
-		}
-		return err
+		return fmt.Errorf("random error occurred during collection")
 	}
 
 	// Generate random metrics
-	if err := rc.generateCounterMetrics(spanCtx, collectorSpan); err != nil {
-		return err
-	}
-
-	if err := rc.generateGaugeMetrics(spanCtx, collectorSpan); err != nil {
+	if err := rc.generateCounterMetrics(ctx); err != nil {
 		return err
 	}
 
-	if err := rc.generateHistogramMetrics(spanCtx, collectorSpan); err != nil {
+	if err := rc.generateGaugeMetrics(ctx); err != nil {
 		return err
 	}
 
-	if err := rc.generateSummaryMetrics(spanCtx, collectorSpan); err != nil {
+	if err := rc.generateHistogramMetrics(ctx); err != nil {
 		return err
 	}
 
-	if err := rc.generateInfoMetrics(spanCtx, collectorSpan); err != nil {
+	if err := rc.generateSummaryMetrics(ctx); err != nil {
 		return err
 	}
 
-	// Add event for successful collection
-	if collectorSpan != nil {
-		collectorSpan.AddEvent("collection_completed",
-			attribute.String("metrics_generated", fmt.Sprintf("%d", rc.config.Random.MetricCount)))
-	}
-
-	return nil
+	return rc.generateInfoMetrics(ctx)
 }
 
 // generateCounterMetrics generates random counter metrics
-func (rc *RandomCollector) generateCounterMetrics(ctx context.Context, span *tracing.CollectorSpan) error {
+func (rc *RandomCollector) generateCounterMetrics(ctx context.Context) error {
 	// Create a sub-span for counter metrics
 	tracer := rc.app.GetTracer()
 	var counterSpan *tracing.CollectorSpan
@@ -318,7 +256,7 @@ func (rc *RandomCollector) generateCounterMetrics(ctx context.Context, span *tra
 }
 
 // generateGaugeMetrics generates random gauge metrics
-func (rc *RandomCollector) generateGaugeMetrics(ctx context.Context, span *tracing.CollectorSpan) error {
+func (rc *RandomCollector) generateGaugeMetrics(ctx context.Context) error {
 	tracer := rc.app.GetTracer()
 	var gaugeSpan *tracing.CollectorSpan
 
@@ -366,7 +304,7 @@ func (rc *RandomCollector) generateGaugeMetrics(ctx context.Context, span *traci
 }
 
 // generateHistogramMetrics generates random histogram metrics
-func (rc *RandomCollector) generateHistogramMetrics(ctx context.Context, span *tracing.CollectorSpan) error {
+func (rc *RandomCollector) generateHistogramMetrics(ctx context.Context) error {
 	tracer := rc.app.GetTracer()
 	var histogramSpan *tracing.CollectorSpan
 
@@ -409,7 +347,7 @@ func (rc *RandomCollector) generateHistogramMetrics(ctx context.Context, span *t
 }
 
 // generateSummaryMetrics generates random summary metrics
-func (rc *RandomCollector) generateSummaryMetrics(ctx context.Context, span *tracing.CollectorSpan) error {
+func (rc *RandomCollector) generateSummaryMetrics(ctx context.Context) error {
 	tracer := rc.app.GetTracer()
 	var summarySpan *tracing.CollectorSpan
 
@@ -452,7 +390,7 @@ func (rc *RandomCollector) generateSummaryMetrics(ctx context.Context, span *tra
 }
 
 // generateInfoMetrics generates info metrics
-func (rc *RandomCollector) generateInfoMetrics(ctx context.Context, span *tracing.CollectorSpan) error {
+func (rc *RandomCollector) generateInfoMetrics(ctx context.Context) error {
 	tracer := rc.app.GetTracer()
 	var infoSpan *tracing.CollectorSpan
 
@@ -475,162 +413,20 @@ func (rc *RandomCollector) generateInfoMetrics(ctx context.Context, span *tracin
 	return nil
 }
 
-// loadFromEnv loads configuration from environment variables
-func loadFromEnv() (*RandomExporterConfig, error) {
-	cfg := &RandomExporterConfig{}
-
-	// Load base configuration from environment
-	baseConfig := &cfg.BaseConfig
-
-	// Server configuration
-	if host := os.Getenv("SERVER_HOST"); host != "" {
-		baseConfig.Server.Host = host
-	} else {
-		baseConfig.Server.Host = "0.0.0.0"
-	}
-
-	if portStr := os.Getenv("SERVER_PORT"); portStr != "" {
-		if port, err := parseInt(portStr); err != nil {
-			return nil, fmt.Errorf("invalid server port: %w", err)
-		} else {
-			baseConfig.Server.Port = port
-		}
-	} else {
-		baseConfig.Server.Port = 8080
-	}
-
-	// Logging configuration
-	if level := os.Getenv("LOG_LEVEL"); level != "" {
-		baseConfig.Logging.Level = level
-	} else {
-		baseConfig.Logging.Level = "info"
-	}
-
-	if format := os.Getenv("LOG_FORMAT"); format != "" {
-		baseConfig.Logging.Format = format
-	} else {
-		baseConfig.Logging.Format = "json"
-	}
-
-	// Tracing configuration
-	if enabledStr := os.Getenv("TRACING_ENABLED"); enabledStr != "" {
-		enabled := enabledStr == "true"
-		baseConfig.Tracing.Enabled = &enabled
-	}
-
-	if serviceName := os.Getenv("TRACING_SERVICE_NAME"); serviceName != "" {
-		baseConfig.Tracing.ServiceName = serviceName
-	}
-
-	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
-		baseConfig.Tracing.Endpoint = endpoint
-	}
-
-	// Random configuration
-	if intervalStr := os.Getenv("RANDOM_COLLECTION_INTERVAL"); intervalStr != "" {
-		if interval, err := time.ParseDuration(intervalStr); err != nil {
-			return nil, fmt.Errorf("invalid collection interval: %w", err)
-		} else {
-			cfg.Random.CollectionInterval = config.Duration{Duration: interval}
-		}
-	} else {
-		cfg.Random.CollectionInterval = config.Duration{Duration: time.Second * 10}
-	}
-
-	if countStr := os.Getenv("RANDOM_METRIC_COUNT"); countStr != "" {
-		if count, err := parseInt(countStr); err != nil {
-			return nil, fmt.Errorf("invalid metric count: %w", err)
-		} else {
-			cfg.Random.MetricCount = count
-		}
-	} else {
-		cfg.Random.MetricCount = 20
-	}
-
-	if enableErrorsStr := os.Getenv("RANDOM_ENABLE_ERRORS"); enableErrorsStr != "" {
-		cfg.Random.EnableRandomErrors = enableErrorsStr == "true"
-	} else {
-		cfg.Random.EnableRandomErrors = false
-	}
-
-	if probStr := os.Getenv("RANDOM_ERROR_PROBABILITY"); probStr != "" {
-		if prob, err := parseFloat(probStr); err != nil {
-			return nil, fmt.Errorf("invalid error probability: %w", err)
-		} else {
-			cfg.Random.ErrorProbability = prob
-		}
-	} else {
-		cfg.Random.ErrorProbability = 0.1
-	}
-
-	cfg.BaseConfig = *baseConfig
-	return cfg, nil
-}
-
-// parseInt parses a string to int
-func parseInt(s string) (int, error) {
-	var result int
-	_, err := fmt.Sscanf(s, "%d", &result)
-	return result, err
-}
-
-// parseFloat parses a string to float64
-func parseFloat(s string) (float64, error) {
-	var result float64
-	_, err := fmt.Sscanf(s, "%f", &result)
-	return result, err
-}
-
 func main() {
-	// Parse command line flags
-	var showVersion bool
-	flag.BoolVar(&showVersion, "version", false, "Show version information")
-	flag.BoolVar(&showVersion, "v", false, "Show version information")
-
-	var (
-		configPath    string
-		configFromEnv bool
-		showConfig    bool
-	)
-
-	flag.StringVar(&configPath, "config", "config.yaml", "Path to configuration file")
-	flag.BoolVar(&configFromEnv, "config-from-env", false, "Load configuration from environment variables only")
-	flag.BoolVar(&showConfig, "show-config", false, "Show loaded configuration and exit")
-	flag.Parse()
-
-	// Show version if requested
-	if showVersion {
-		fmt.Printf("random-exporter v1.0.0\n")
-		fmt.Printf("Build Date: %s\n", time.Now().Format("2006-01-02"))
-		fmt.Printf("Go Version: %s\n", "1.21")
-		os.Exit(0)
-	}
-
-	// Load configuration
-	var cfg *RandomExporterConfig
-	var err error
+	versionInfo := &version.Info{Version: "1.0.0", Commit: "example-commit", BuildDate: time.Now().Format("2006-01-02")}
 
-	if configFromEnv || os.Getenv("CONFIG_FROM_ENV") == "true" {
-		cfg, err = loadFromEnv()
-	} else {
-		// For this example, we'll use environment-based config
-		cfg, err = loadFromEnv()
-	}
-
-	if err != nil {
-		slog.Error("Failed to load configuration", "error", err)
-		os.Exit(1)
-	}
-
-	// Show configuration if requested
-	if showConfig {
-		displayConfig := cfg.BaseConfig.GetDisplayConfig()
-		fmt.Printf("Configuration:\n")
-		for key, value := range displayConfig {
-			fmt.Printf("  %s: %v\n", key, value)
-		}
-		os.Exit(0)
+	// Defaults for the exporter's own settings. cli.Load layers config files,
+	// environment variables (optionally prefixed with RANDOM_EXPORTER_) and
+	// -set flags on top, and handles -version, -show-config and -check-config.
+	cfg := &RandomExporterConfig{
+		Random: RandomConfig{
+			CollectionInterval: config.Duration{Duration: time.Second * 10},
+			MetricCount:        20,
+			ErrorProbability:   0.1,
+		},
 	}
+	cli.Load(cfg, cli.Options{Name: "random-exporter", EnvPrefix: "RANDOM_EXPORTER_", Version: versionInfo})
 
 	// Configure logging using promexporter
 	logging.Configure(&logging.Config{
@@ -639,8 +435,7 @@ func main() {
 	})
 
 	slog.Info("Starting Random Exporter",
-		"version", "1.0.0",
-		"config_from_env", configFromEnv || os.Getenv("CONFIG_FROM_ENV") == "true",
+		"version", versionInfo.Version,
 		"tracing_enabled", cfg.BaseConfig.Tracing.IsEnabled(),
 	)
 
@@ -648,47 +443,29 @@ func main() {
 	metricsRegistry := promexporter_metrics.NewRegistry("random_exporter_info")
 
 	// Create random metrics
-	randomMetrics := NewRandomMetrics(metricsRegistry)
-	randomMetrics.RegisterMetrics(metricsRegistry)
+	randomMetrics, err := NewRandomMetrics(metricsRegistry)
+	if err != nil {
+		slog.Error("Failed to create metrics", "error", err)
+		os.Exit(1)
+	}
 
 	// Create and run application using promexporter
 	application := app.New("Random Exporter").
 		WithConfig(&cfg.BaseConfig).
 		WithMetrics(metricsRegistry).
-		WithVersionInfo("1.0.0", "example-commit", time.Now().Format("2006-01-02"))
+		WithVersionInfo(versionInfo.Version, versionInfo.Commit, versionInfo.BuildDate)
 
-	// Create collector with app reference for tracing
+	// Create collector with app reference for tracing. The app drives the
+	// collection loop on the collector's interval.
 	randomCollector := NewRandomCollector(cfg, randomMetrics, application)
-	application.WithCollector(randomCollector)
-
-	// Set up graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle shutdown signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		sig := <-sigChan
-		slog.Info("Received shutdown signal", "signal", sig.String())
-		cancel()
-	}()
-
-	// Start the application in a goroutine
-	appErr := make(chan error, 1)
-	go func() {
-		appErr <- application.Build().Run()
-	}()
-
-	// Wait for application to finish or context cancellation
-	select {
-	case err := <-appErr:
-		if err != nil {
-			slog.Error("Application error", "error", err)
-			os.Exit(1)
-		}
-	case <-ctx.Done():
-		slog.Info("Shutting down gracefully")
+	application.WithScheduledCollector(randomCollector)
+
+	// Run until SIGINT or SIGTERM is received
+	ctx, stop := app.SignalContext(context.Background())
+	defer stop()
+
+	if err := application.Build().RunContext(ctx); err != nil {
+		slog.Error("Application error", "error", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FailureThreshold is the number of consecutive failures after which a
+// collector is no longer considered ready. A single failed run is tolerated
+// so that one transient error doesn't pull the exporter out of service.
+const FailureThreshold = 3
+
+// Status describes the health of a single collector
+type Status struct {
+	Name                string
+	Required            bool
+	LastSuccess         time.Time
+	LastError           string
+	LastErrorTime       time.Time
+	ConsecutiveFailures int
+}
+
+// Ready returns true if the collector has completed at least one successful
+// collection and is not currently failing
+func (s Status) Ready() bool {
+	return !s.LastSuccess.IsZero() && !s.Failing()
+}
+
+// Failing returns true if the collector has failed FailureThreshold or more
+// times in a row
+func (s Status) Failing() bool {
+	return s.ConsecutiveFailures >= FailureThreshold
+}
+
+// AllReady returns true if every required collector in statuses is ready
+func AllReady(statuses []Status) bool {
+	for _, status := range statuses {
+		if status.Required && !status.Ready() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AnyFailing returns true if any required collector in statuses is failing
+func AnyFailing(statuses []Status) bool {
+	for _, status := range statuses {
+		if status.Required && status.Failing() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tracker records the outcome of collector runs
+type Tracker struct {
+	mu       sync.RWMutex
+	statuses map[string]*Status
+}
+
+// NewTracker creates a new, empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		statuses: make(map[string]*Status),
+	}
+}
+
+// Register adds a collector to the tracker before its first run so that it
+// is reported as not ready until it has succeeded
+func (t *Tracker) Register(name string, required bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if status, ok := t.statuses[name]; ok {
+		status.Required = required
+		return
+	}
+
+	t.statuses[name] = &Status{Name: name, Required: required}
+}
+
+// RecordSuccess records a successful run
+func (t *Tracker) RecordSuccess(name string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := t.get(name)
+	status.LastSuccess = at
+	status.ConsecutiveFailures = 0
+}
+
+// RecordFailure records a failed run
+func (t *Tracker) RecordFailure(name string, err error, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := t.get(name)
+	status.LastError = err.Error()
+	status.LastErrorTime = at
+	status.ConsecutiveFailures++
+}
+
+// Statuses returns a snapshot of all tracked collectors sorted by name
+func (t *Tracker) Statuses() []Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(t.statuses))
+	for _, status := range t.statuses {
+		statuses = append(statuses, *status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// get returns the status for name, creating a required entry for
+// collectors that were never registered. Callers must hold t.mu.
+func (t *Tracker) get(name string) *Status {
+	status, ok := t.statuses[name]
+	if !ok {
+		status = &Status{Name: name, Required: true}
+		t.statuses[name] = status
+	}
+
+	return status
+}
@@ -1,34 +1,46 @@
 package logging
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Config holds logging configuration
 type Config struct {
 	Level  string
 	Format string
+
+	// DisableRedaction logs sensitive values verbatim. By default they are
+	// redacted by a RedactingHandler.
+	DisableRedaction bool
 }
 
+var (
+	// level is shared by every handler Configure creates, so the level can
+	// be changed at runtime without rebuilding the handler
+	level = new(slog.LevelVar)
+
+	// mu guards the pending auto-revert state. revertLevel is the level
+	// restored when revertTimer fires at revertAt.
+	mu          sync.Mutex
+	revertTimer *time.Timer
+	revertAt    time.Time
+	revertLevel slog.Level
+)
+
 // Configure sets up logging based on the configuration
 func Configure(cfg *Config) {
-	var level slog.Level
-
-	switch strings.ToLower(cfg.Level) {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
+	parsed, err := ParseLevel(cfg.Level)
+	if err != nil {
+		parsed = slog.LevelInfo
 	}
 
+	setLevel(parsed)
+
 	var handler slog.Handler
 	if strings.ToLower(cfg.Format) == "text" {
 		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
@@ -40,5 +52,121 @@ func Configure(cfg *Config) {
 		})
 	}
 
+	if !cfg.DisableRedaction {
+		handler = NewRedactingHandler(handler)
+	}
+
 	slog.SetDefault(slog.New(handler))
 }
+
+// ParseLevel converts a level name (debug, info, warn or error) to a
+// slog.Level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid logging level: %s", name)
+	}
+}
+
+// GetLevel returns the current log level name
+func GetLevel() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// SetLevel changes the log level of the running process, cancelling any
+// pending auto-revert
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+
+	setLevel(parsed)
+
+	return nil
+}
+
+// SetLevelFor changes the log level for duration d, after which it reverts
+// to the level that was active before the first temporary change. It returns
+// the time at which the level will revert.
+func SetLevelFor(name string, d time.Duration) (time.Time, error) {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("revert duration must be positive, got %s", d)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Revert to the level that was in place before any temporary change,
+	// not to a previous temporary level
+	original := level.Level()
+	if revertTimer != nil {
+		revertTimer.Stop()
+
+		original = revertLevel
+	}
+
+	revertLevel = original
+	revertAt = time.Now().Add(d)
+
+	// The callback can't run until mu is released below, so timer is always
+	// assigned by the time it compares against revertTimer. The comparison
+	// skips timers that were replaced or cancelled after they had fired.
+	var timer *time.Timer
+
+	timer = time.AfterFunc(d, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if revertTimer != timer {
+			return
+		}
+
+		level.Set(revertLevel)
+
+		revertTimer = nil
+		revertAt = time.Time{}
+	})
+	revertTimer = timer
+
+	level.Set(parsed)
+
+	return revertAt, nil
+}
+
+// RevertAt returns when a temporary level set by SetLevelFor will revert,
+// or the zero time if no revert is pending
+func RevertAt() time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return revertAt
+}
+
+// setLevel sets the level and cancels any pending revert
+func setLevel(l slog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if revertTimer != nil {
+		revertTimer.Stop()
+
+		revertTimer = nil
+		revertAt = time.Time{}
+	}
+
+	level.Set(l)
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AuthMetrics holds the self-metrics published for HTTP authentication
+type AuthMetrics struct {
+	Failures *prometheus.CounterVec
+}
+
+// AuthMetrics returns the HTTP authentication metrics, registering them on
+// first use so that exporters without authentication don't list them.
+func (r *Registry) AuthMetrics() *AuthMetrics {
+	r.authMetricsOnce.Do(func() {
+		am := &AuthMetrics{
+			Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: r.prefixed("http_auth_failures_total"),
+				Help: "Total number of HTTP requests rejected by authentication",
+			}, []string{"reason"}),
+		}
+
+		r.registry.MustRegister(am.Failures)
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("http_auth_failures_total"),
+			Help:   "Total number of HTTP requests rejected by authentication",
+			Type:   MetricTypeCounter,
+			Labels: []string{"reason"},
+		})
+
+		r.authMetrics = am
+	})
+
+	return r.authMetrics
+}
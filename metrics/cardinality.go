@@ -0,0 +1,288 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// cardinalityWarningInterval is the minimum time between warnings about
+// dropped series for the same metric
+const cardinalityWarningInterval = time.Minute
+
+// CardinalityLimits cap how many series the registry exposes, protecting
+// Prometheus from a collector that labels with unbounded values. Zero means
+// no limit.
+type CardinalityLimits struct {
+	MaxSeries          int            // Across all metrics
+	MaxSeriesPerMetric int            // For each metric
+	MetricLimits       map[string]int // By exposed metric name, overriding MaxSeriesPerMetric
+}
+
+// SeriesCount is the number of series a metric has, for finding the metrics
+// with the highest cardinality
+type SeriesCount struct {
+	Name    string
+	Series  int // Before cardinality limits are applied
+	Limit   int // Zero when there is no per-metric limit
+	Dropped int // Series dropped by the limits on the last gather
+}
+
+// enabled reports whether any limit is set
+func (l CardinalityLimits) enabled() bool {
+	return l.MaxSeries > 0 || l.MaxSeriesPerMetric > 0 || len(l.MetricLimits) > 0
+}
+
+// metricLimit returns the series limit for the metric called name, or zero
+func (l CardinalityLimits) metricLimit(name string) int {
+	if limit, ok := l.MetricLimits[name]; ok {
+		return limit
+	}
+
+	return l.MaxSeriesPerMetric
+}
+
+// cardinalityLimiter drops series past the limits. Series exposed by the
+// previous gather keep their place, so it is new series that are dropped,
+// and a series that goes away frees its place for another.
+type cardinalityLimiter struct {
+	mu          sync.Mutex
+	limits      CardinalityLimits
+	admitted    map[string]map[string]bool // Series exposed by the last gather, by metric
+	dropped     map[string]map[string]bool // Series dropped by the last gather, by metric
+	lastWarning map[string]time.Time
+}
+
+// SetCardinalityLimits changes the series limits. It can be called at any
+// time, such as after a configuration reload; the series already exposed
+// keep their place under the new limits.
+func (r *Registry) SetCardinalityLimits(limits CardinalityLimits) error {
+	if limits.MaxSeries < 0 || limits.MaxSeriesPerMetric < 0 {
+		return fmt.Errorf("series limits must not be negative")
+	}
+
+	for name, limit := range limits.MetricLimits {
+		if limit < 0 {
+			return fmt.Errorf("series limit for %s must not be negative", name)
+		}
+	}
+
+	r.transformMu.Lock()
+	defer r.transformMu.Unlock()
+
+	if !limits.enabled() {
+		r.limiter = nil
+
+		return nil
+	}
+
+	r.seriesDroppedCounter()
+
+	if r.limiter == nil {
+		r.limiter = &cardinalityLimiter{lastWarning: make(map[string]time.Time)}
+	}
+
+	r.limiter.mu.Lock()
+	r.limiter.limits = limits
+	r.limiter.mu.Unlock()
+
+	return nil
+}
+
+// SeriesCounts returns the number of series each metric currently exposes,
+// highest first, with the limits that apply to it
+func (r *Registry) SeriesCounts() ([]SeriesCount, error) {
+	families, err := r.gather(context.Background())
+
+	transform, limiter := r.currentTransform(), r.currentLimiter()
+	if transform != nil {
+		transform.apply(families)
+	}
+
+	counts := make([]SeriesCount, 0, len(families))
+
+	for _, family := range families {
+		count := SeriesCount{Name: family.GetName(), Series: len(family.Metric)}
+
+		if limiter != nil {
+			limiter.mu.Lock()
+			count.Limit = limiter.limits.metricLimit(count.Name)
+			count.Dropped = len(limiter.dropped[count.Name])
+			limiter.mu.Unlock()
+		}
+
+		counts = append(counts, count)
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Series != counts[j].Series {
+			return counts[i].Series > counts[j].Series
+		}
+
+		return counts[i].Name < counts[j].Name
+	})
+
+	return counts, err
+}
+
+// MaxSeries returns the limit on series across all metrics, or zero
+func (r *Registry) MaxSeries() int {
+	if limiter := r.currentLimiter(); limiter != nil {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+
+		return limiter.limits.MaxSeries
+	}
+
+	return 0
+}
+
+// currentLimiter returns the cardinality limiter, or nil if there are no
+// limits
+func (r *Registry) currentLimiter() *cardinalityLimiter {
+	r.transformMu.RLock()
+	defer r.transformMu.RUnlock()
+
+	return r.limiter
+}
+
+// seriesDroppedCounter returns the counter of series dropped by the limits,
+// registering it on first use
+func (r *Registry) seriesDroppedCounter() *prometheus.CounterVec {
+	r.seriesDroppedOnce.Do(func() {
+		r.seriesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: r.prefixed("series_dropped_total"),
+			Help: "Total number of new series dropped because a cardinality limit was reached",
+		}, []string{"metric"})
+
+		r.registry.MustRegister(r.seriesDropped)
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("series_dropped_total"),
+			Help:   "Total number of new series dropped because a cardinality limit was reached",
+			Type:   MetricTypeCounter,
+			Labels: []string{"metric"},
+		})
+	})
+
+	return r.seriesDropped
+}
+
+// apply removes the series past the limits from families, except from the
+// family called exempt. It returns the number of series dropped from each
+// metric that weren't already dropped by the previous gather.
+func (l *cardinalityLimiter) apply(families []*dto.MetricFamily, exempt string) map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	admitted := make(map[string]map[string]bool, len(families))
+	dropped := make(map[string]map[string]bool)
+	keep := make(map[*dto.Metric]bool)
+	total := 0
+
+	type candidate struct {
+		name   string
+		key    string
+		metric *dto.Metric
+	}
+
+	// admit keeps a series if there is room for it under the limits
+	admit := func(c candidate) bool {
+		limit := l.limits.metricLimit(c.name)
+		if (limit > 0 && len(admitted[c.name]) >= limit) || (l.limits.MaxSeries > 0 && total >= l.limits.MaxSeries) {
+			return false
+		}
+
+		admitted[c.name][c.key] = true
+		keep[c.metric] = true
+		total++
+
+		return true
+	}
+
+	// Series that were exposed last time keep their place, then new series
+	// fill any room that's left
+	var candidates []candidate
+
+	for _, family := range families {
+		name := family.GetName()
+		if name == exempt {
+			continue
+		}
+
+		admitted[name] = make(map[string]bool)
+
+		for _, metric := range family.Metric {
+			c := candidate{name: name, key: seriesKey(metric.Label), metric: metric}
+			if !l.admitted[name][c.key] || !admit(c) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	newlyDropped := make(map[string]int)
+
+	for _, c := range candidates {
+		if admit(c) {
+			continue
+		}
+
+		if dropped[c.name] == nil {
+			dropped[c.name] = make(map[string]bool)
+		}
+
+		dropped[c.name][c.key] = true
+
+		if !l.dropped[c.name][c.key] {
+			newlyDropped[c.name]++
+		}
+	}
+
+	for _, family := range families {
+		if family.GetName() == exempt || len(dropped[family.GetName()]) == 0 {
+			continue
+		}
+
+		metrics := family.Metric[:0]
+
+		for _, metric := range family.Metric {
+			if keep[metric] {
+				metrics = append(metrics, metric)
+			}
+		}
+
+		family.Metric = metrics
+	}
+
+	l.admitted = admitted
+	l.dropped = dropped
+
+	return newlyDropped
+}
+
+// warn logs that series were dropped from metric, at most once per
+// cardinalityWarningInterval for each metric
+func (l *cardinalityLimiter) warn(metric string, count int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastWarning[metric]) < cardinalityWarningInterval {
+		return
+	}
+
+	l.lastWarning[metric] = now
+
+	slog.Warn("Dropping new series because a cardinality limit was reached",
+		"metric", metric,
+		"dropped", count,
+		"limit", l.limits.metricLimit(metric),
+		"max_series", l.limits.MaxSeries,
+	)
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TestCardinalityLimits asserts new series past a limit are dropped and
+// counted, while series that were already exposed keep their place.
+func TestCardinalityLimits(t *testing.T) {
+	registry := NewRegistry("cardinality_test_info")
+	if err := registry.SetCardinalityLimits(CardinalityLimits{MaxSeriesPerMetric: 2}); err != nil {
+		t.Fatalf("SetCardinalityLimits: %v", err)
+	}
+
+	requests, err := registry.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests"}, []string{"user"})
+	if err != nil {
+		t.Fatalf("NewCounterVec: %v", err)
+	}
+
+	// The later users sort before these, so they would take their place if
+	// the limiter didn't remember what it exposed
+	requests.WithLabelValues("x").Inc()
+	requests.WithLabelValues("y").Inc()
+	gather(t, registry)
+
+	for _, user := range []string{"a", "b", "c"} {
+		requests.WithLabelValues(user).Inc()
+	}
+
+	families := gather(t, registry)
+
+	family := findFamily(families, "requests_total")
+	if len(family.Metric) != 2 || family.Metric[0].Label[0].GetValue() != "x" || family.Metric[1].Label[0].GetValue() != "y" {
+		t.Fatalf("expected the original two series to be kept, got %v", family.Metric)
+	}
+
+	// The drop is counted once, not on every gather
+	gather(t, registry)
+
+	dropped := findFamily(gather(t, registry), "cardinality_test_series_dropped_total")
+	if dropped == nil || dropped.Metric[0].GetCounter().GetValue() != 3 {
+		t.Fatalf("expected 3 dropped series to be counted, got %v", dropped)
+	}
+
+	counts, err := registry.SeriesCounts()
+	if err != nil {
+		t.Fatalf("SeriesCounts: %v", err)
+	}
+
+	for _, count := range counts {
+		if count.Name == "requests_total" && (count.Series != 5 || count.Limit != 2 || count.Dropped != 3) {
+			t.Errorf("unexpected series count: %+v", count)
+		}
+	}
+}
+
+// TestCardinalityLimits_Global asserts the limit across all metrics applies.
+func TestCardinalityLimits_Global(t *testing.T) {
+	registry := NewRegistry("cardinality_test_info")
+
+	gauge, err := registry.NewGaugeVec(prometheus.GaugeOpts{Name: "items", Help: "Items"}, []string{"id"})
+	if err != nil {
+		t.Fatalf("NewGaugeVec: %v", err)
+	}
+
+	for i := range 10 {
+		gauge.WithLabelValues(fmt.Sprint(i)).Set(1)
+	}
+
+	before := 0
+	for _, family := range gather(t, registry) {
+		before += len(family.Metric)
+	}
+
+	if err := registry.SetCardinalityLimits(CardinalityLimits{MaxSeries: before - 5}); err != nil {
+		t.Fatalf("SetCardinalityLimits: %v", err)
+	}
+
+	after := 0
+
+	for _, family := range gather(t, registry) {
+		if family.GetName() != "cardinality_test_series_dropped_total" {
+			after += len(family.Metric)
+		}
+	}
+
+	if after != before-5 {
+		t.Errorf("expected %d series, got %d", before-5, after)
+	}
+}
+
+func gather(t *testing.T, registry *Registry) []*dto.MetricFamily {
+	t.Helper()
+
+	families, err := registry.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	return families
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CollectorMetrics holds the self-metrics published for every scheduled
+// collector run, labelled by collector name
+type CollectorMetrics struct {
+	Duration    *prometheus.HistogramVec
+	LastSuccess *prometheus.GaugeVec
+	Errors      *prometheus.CounterVec
+	Runs        *prometheus.CounterVec
+}
+
+// collectorLabels are the labels shared by all collector self-metrics
+var collectorLabels = []string{"collector"}
+
+// CollectorMetrics returns the collector self-metrics, registering them on
+// first use so that exporters without scheduled collectors don't list them.
+func (r *Registry) CollectorMetrics() *CollectorMetrics {
+	r.collectorMetricsOnce.Do(func() {
+		cm := &CollectorMetrics{
+			Duration: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    r.prefixed("collector_duration_seconds"),
+					Help:    "Duration of collector runs in seconds",
+					Buckets: prometheus.DefBuckets,
+				},
+				collectorLabels,
+			),
+			LastSuccess: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Name: r.prefixed("collector_last_success_timestamp_seconds"),
+					Help: "Unix timestamp of the last successful collector run",
+				},
+				collectorLabels,
+			),
+			Errors: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: r.prefixed("collector_errors_total"),
+					Help: "Total number of failed collector runs",
+				},
+				collectorLabels,
+			),
+			Runs: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: r.prefixed("collector_runs_total"),
+					Help: "Total number of collector runs",
+				},
+				collectorLabels,
+			),
+		}
+
+		r.registry.MustRegister(cm.Duration, cm.LastSuccess, cm.Errors, cm.Runs)
+
+		r.addMetricInfo(MetricInfo{
+			Name:    r.prefixed("collector_duration_seconds"),
+			Help:    "Duration of collector runs in seconds",
+			Type:    MetricTypeHistogram,
+			Labels:  collectorLabels,
+			Buckets: prometheus.DefBuckets,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("collector_last_success_timestamp_seconds"),
+			Help:   "Unix timestamp of the last successful collector run",
+			Type:   MetricTypeGauge,
+			Labels: collectorLabels,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("collector_errors_total"),
+			Help:   "Total number of failed collector runs",
+			Type:   MetricTypeCounter,
+			Labels: collectorLabels,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("collector_runs_total"),
+			Help:   "Total number of collector runs",
+			Type:   MetricTypeCounter,
+			Labels: collectorLabels,
+		})
+
+		r.collectorMetrics = cm
+	})
+
+	return r.collectorMetrics
+}
+
+// Init creates the series for a collector so that they are exported (as
+// zero) before the first run completes. This keeps rate() and absent()
+// queries well-behaved from the moment the exporter starts.
+func (cm *CollectorMetrics) Init(collector string) {
+	cm.Duration.WithLabelValues(collector)
+	cm.Errors.WithLabelValues(collector)
+	cm.Runs.WithLabelValues(collector)
+}
+
+// Observe records the outcome of a single collector run
+func (cm *CollectorMetrics) Observe(collector string, seconds float64, err error) {
+	cm.Runs.WithLabelValues(collector).Inc()
+	cm.Duration.WithLabelValues(collector).Observe(seconds)
+
+	if err != nil {
+		cm.Errors.WithLabelValues(collector).Inc()
+		return
+	}
+
+	cm.LastSuccess.WithLabelValues(collector).SetToCurrentTime()
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ExpiringGaugeVec is a GaugeVec whose series are deleted once they go
+// stale, so a device or target that disappears stops being exported rather
+// than reporting its last value forever.
+//
+// A series is stale when it hasn't been updated for longer than the TTL, or,
+// when a collector brackets its updates with StartCycle and EndCycle, when
+// it wasn't updated during the last completed cycle. Stale series are
+// deleted when the vector is collected and at the end of a cycle.
+type ExpiringGaugeVec struct {
+	vec        *prometheus.GaugeVec
+	labelNames []string
+	ttl        time.Duration
+
+	mu         sync.Mutex
+	series     map[string]expiringSeries
+	cycleStart time.Time
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// expiringSeries records when a series was last updated
+type expiringSeries struct {
+	labelValues []string
+	updated     time.Time
+}
+
+// ExpiringGauge is a single series of an ExpiringGaugeVec. Every update
+// refreshes the series, recreating it if it had expired.
+type ExpiringGauge struct {
+	parent      *ExpiringGaugeVec
+	labelValues []string
+}
+
+// NewExpiringGaugeVec creates an ExpiringGaugeVec whose series are deleted
+// after ttl without an update. A zero ttl means series only expire through
+// StartCycle and EndCycle. The vector must be registered to be exported;
+// Registry.NewExpiringGaugeVec does both.
+func NewExpiringGaugeVec(opts prometheus.GaugeOpts, labelNames []string, ttl time.Duration) *ExpiringGaugeVec {
+	return &ExpiringGaugeVec{
+		vec:        prometheus.NewGaugeVec(opts, labelNames),
+		labelNames: labelNames,
+		ttl:        ttl,
+		series:     make(map[string]expiringSeries),
+		now:        time.Now,
+	}
+}
+
+// NewExpiringGaugeVec creates an ExpiringGaugeVec, registers it and adds it
+// to the metric list shown in the UI. It fails if a metric with the same
+// name exists.
+func (r *Registry) NewExpiringGaugeVec(opts prometheus.GaugeOpts, labelNames []string, ttl time.Duration) (*ExpiringGaugeVec, error) {
+	vec := NewExpiringGaugeVec(opts, labelNames, ttl)
+
+	info := MetricInfo{
+		Name:   prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		Help:   opts.Help,
+		Type:   MetricTypeGauge,
+		Labels: labelNames,
+	}
+	if err := r.register(info, vec); err != nil {
+		return nil, err
+	}
+
+	return vec, nil
+}
+
+// WithLabelValues returns the series for the given label values, in the
+// order the label names were given. The values are copied, so the caller
+// can reuse the slice.
+func (v *ExpiringGaugeVec) WithLabelValues(labelValues ...string) *ExpiringGauge {
+	return &ExpiringGauge{parent: v, labelValues: slices.Clone(labelValues)}
+}
+
+// With returns the series for the given labels
+func (v *ExpiringGaugeVec) With(labels prometheus.Labels) *ExpiringGauge {
+	labelValues := make([]string, len(v.labelNames))
+	for i, name := range v.labelNames {
+		labelValues[i] = labels[name]
+	}
+
+	return v.WithLabelValues(labelValues...)
+}
+
+// DeleteLabelValues deletes the series for the given label values, returning
+// true if it existed
+func (v *ExpiringGaugeVec) DeleteLabelValues(labelValues ...string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.series, seriesID(labelValues))
+
+	return v.vec.DeleteLabelValues(labelValues...)
+}
+
+// Reset deletes every series
+func (v *ExpiringGaugeVec) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.series = make(map[string]expiringSeries)
+	v.vec.Reset()
+}
+
+// StartCycle marks the start of a collection cycle. Call EndCycle when the
+// cycle completes to delete every series that wasn't updated during it. If
+// the cycle fails, skip EndCycle to keep the series from the last cycle.
+func (v *ExpiringGaugeVec) StartCycle() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.cycleStart = v.now()
+}
+
+// EndCycle deletes every series that wasn't updated since StartCycle
+func (v *ExpiringGaugeVec) EndCycle() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.cycleStart.IsZero() {
+		return
+	}
+
+	v.deleteUpdatedBefore(v.cycleStart)
+	v.cycleStart = time.Time{}
+}
+
+// Describe implements prometheus.Collector
+func (v *ExpiringGaugeVec) Describe(ch chan<- *prometheus.Desc) {
+	v.vec.Describe(ch)
+}
+
+// Collect implements prometheus.Collector, deleting series older than the
+// TTL before collecting the rest
+func (v *ExpiringGaugeVec) Collect(ch chan<- prometheus.Metric) {
+	v.mu.Lock()
+	if v.ttl > 0 {
+		v.deleteUpdatedBefore(v.now().Add(-v.ttl))
+	}
+	v.mu.Unlock()
+
+	v.vec.Collect(ch)
+}
+
+// update applies fn to the series for labelValues and records the update.
+// It holds mu so a series can't expire between being updated and recorded.
+func (v *ExpiringGaugeVec) update(labelValues []string, fn func(prometheus.Gauge)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fn(v.vec.WithLabelValues(labelValues...))
+
+	v.series[seriesID(labelValues)] = expiringSeries{labelValues: labelValues, updated: v.now()}
+}
+
+// deleteUpdatedBefore deletes the series last updated before cutoff.
+// Callers must hold mu.
+func (v *ExpiringGaugeVec) deleteUpdatedBefore(cutoff time.Time) {
+	for id, series := range v.series {
+		if series.updated.Before(cutoff) {
+			v.vec.DeleteLabelValues(series.labelValues...)
+			delete(v.series, id)
+		}
+	}
+}
+
+// Set sets the gauge to value
+func (g *ExpiringGauge) Set(value float64) {
+	g.parent.update(g.labelValues, func(gauge prometheus.Gauge) { gauge.Set(value) })
+}
+
+// Inc increments the gauge by 1
+func (g *ExpiringGauge) Inc() {
+	g.parent.update(g.labelValues, func(gauge prometheus.Gauge) { gauge.Inc() })
+}
+
+// Dec decrements the gauge by 1
+func (g *ExpiringGauge) Dec() {
+	g.parent.update(g.labelValues, func(gauge prometheus.Gauge) { gauge.Dec() })
+}
+
+// Add adds value to the gauge
+func (g *ExpiringGauge) Add(value float64) {
+	g.parent.update(g.labelValues, func(gauge prometheus.Gauge) { gauge.Add(value) })
+}
+
+// Sub subtracts value from the gauge
+func (g *ExpiringGauge) Sub(value float64) {
+	g.parent.update(g.labelValues, func(gauge prometheus.Gauge) { gauge.Sub(value) })
+}
+
+// SetToCurrentTime sets the gauge to the current Unix time in seconds
+func (g *ExpiringGauge) SetToCurrentTime() {
+	g.parent.update(g.labelValues, func(gauge prometheus.Gauge) { gauge.SetToCurrentTime() })
+}
+
+// seriesID identifies a series by its label values
+func seriesID(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
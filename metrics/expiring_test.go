@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestExpiringGaugeVec_TTL asserts series are deleted once they haven't been
+// updated for longer than the TTL, and come back when they are set again.
+func TestExpiringGaugeVec_TTL(t *testing.T) {
+	registry := NewRegistry("expiring_test_info")
+
+	vec, err := registry.NewExpiringGaugeVec(prometheus.GaugeOpts{
+		Name: "device_temperature",
+		Help: "Temperature",
+	}, []string{"device"}, time.Minute)
+	if err != nil {
+		t.Fatalf("NewExpiringGaugeVec: %v", err)
+	}
+
+	now := time.Unix(1000, 0)
+	vec.now = func() time.Time { return now }
+
+	vec.WithLabelValues("a").Set(20)
+	vec.With(prometheus.Labels{"device": "b"}).Set(21)
+
+	now = now.Add(45 * time.Second)
+	vec.WithLabelValues("a").Inc()
+
+	if got := seriesCount(t, registry, "device_temperature"); got != 2 {
+		t.Fatalf("expected 2 series before the TTL, got %d", got)
+	}
+
+	now = now.Add(30 * time.Second)
+
+	if got := seriesCount(t, registry, "device_temperature"); got != 1 {
+		t.Fatalf("expected b to expire, got %d series", got)
+	}
+
+	vec.WithLabelValues("b").Set(22)
+
+	if got := seriesCount(t, registry, "device_temperature"); got != 2 {
+		t.Errorf("expected b to come back when set, got %d series", got)
+	}
+}
+
+// TestExpiringGaugeVec_ReusedLabelValues asserts a label value slice reused
+// across calls doesn't change the series already recorded.
+func TestExpiringGaugeVec_ReusedLabelValues(t *testing.T) {
+	registry := NewRegistry("expiring_test_info")
+
+	vec, err := registry.NewExpiringGaugeVec(prometheus.GaugeOpts{
+		Name: "device_temperature",
+		Help: "Temperature",
+	}, []string{"device"}, time.Minute)
+	if err != nil {
+		t.Fatalf("NewExpiringGaugeVec: %v", err)
+	}
+
+	now := time.Unix(1000, 0)
+	vec.now = func() time.Time { return now }
+
+	labelValues := []string{"a"}
+	gauge := vec.WithLabelValues(labelValues...)
+
+	for _, device := range []string{"a", "b", "c"} {
+		labelValues[0] = device
+		vec.WithLabelValues(labelValues...).Set(20)
+	}
+
+	// The gauge was created before the slice changed, so it still updates a
+	gauge.Set(21)
+
+	if got := seriesCount(t, registry, "device_temperature"); got != 3 {
+		t.Fatalf("expected 3 series, got %d", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if got := seriesCount(t, registry, "device_temperature"); got != 0 {
+		t.Errorf("expected every series to expire, got %d", got)
+	}
+}
+
+// TestExpiringGaugeVec_Cycle asserts EndCycle deletes the series that
+// weren't updated since StartCycle.
+func TestExpiringGaugeVec_Cycle(t *testing.T) {
+	registry := NewRegistry("expiring_test_info")
+
+	vec, err := registry.NewExpiringGaugeVec(prometheus.GaugeOpts{
+		Name: "device_up",
+		Help: "Up",
+	}, []string{"device"}, 0)
+	if err != nil {
+		t.Fatalf("NewExpiringGaugeVec: %v", err)
+	}
+
+	now := time.Unix(1000, 0)
+	vec.now = func() time.Time { return now }
+
+	vec.StartCycle()
+	vec.WithLabelValues("a").Set(1)
+	vec.WithLabelValues("b").Set(1)
+	vec.EndCycle()
+
+	// A failed cycle doesn't call EndCycle, so nothing is deleted
+	now = now.Add(time.Second)
+	vec.StartCycle()
+
+	if got := seriesCount(t, registry, "device_up"); got != 2 {
+		t.Fatalf("expected 2 series after an unfinished cycle, got %d", got)
+	}
+
+	now = now.Add(time.Second)
+	vec.StartCycle()
+	vec.WithLabelValues("a").Set(1)
+	vec.EndCycle()
+
+	if got := seriesCount(t, registry, "device_up"); got != 1 {
+		t.Errorf("expected b to be deleted at the end of the cycle, got %d series", got)
+	}
+}
+
+func seriesCount(t *testing.T, registry *Registry, name string) int {
+	t.Helper()
+
+	family := findFamily(gather(t, registry), name)
+	if family == nil {
+		return 0
+	}
+
+	return len(family.Metric)
+}
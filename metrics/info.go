@@ -1,9 +1,19 @@
 package metrics
 
+// Metric types recorded in MetricInfo
+const (
+	MetricTypeCounter   = "counter"
+	MetricTypeGauge     = "gauge"
+	MetricTypeHistogram = "histogram"
+	MetricTypeSummary   = "summary"
+)
+
 // MetricInfo contains information about a metric for the UI
 type MetricInfo struct {
 	Name         string
 	Help         string
+	Type         string // One of the MetricType constants, or empty if unknown
 	Labels       []string
+	Buckets      []float64 // Histogram buckets
 	ExampleValue string
 }
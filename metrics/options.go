@@ -0,0 +1,351 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Relabel actions
+const (
+	RelabelDrop   = "drop"   // Remove labels whose name matches Regex
+	RelabelRename = "rename" // Rename labels whose name matches Regex to Replacement
+)
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// RegistryOptions change how every metric in a Registry is exposed. They are
+// applied when metrics are gathered, so they also cover collectors
+// registered directly on GetRegistry().
+type RegistryOptions struct {
+	// Namespace is prepended to every metric name, so "acme" exposes
+	// go_goroutines as acme_go_goroutines
+	Namespace string
+
+	// ConstLabels are added to every series. A label a metric already has
+	// keeps its own value.
+	ConstLabels map[string]string
+
+	// Relabel rules are applied in order, after ConstLabels are added
+	Relabel []RelabelRule
+}
+
+// RelabelRule drops or renames labels before exposition. If dropping a label
+// leaves several series with the same labels, only the first is exposed. A
+// rename whose target isn't a valid label name, or that a series already
+// has, is skipped and the label keeps its name.
+type RelabelRule struct {
+	Action      string // RelabelDrop or RelabelRename
+	Regex       string // Matched against the whole label name
+	Replacement string // New name for RelabelRename; $1 refers to a capture group
+}
+
+// relabelRule is a RelabelRule with its regex compiled
+type relabelRule struct {
+	action      string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// registryTransform is the compiled form of RegistryOptions
+type registryTransform struct {
+	namespace   string
+	constLabels map[string]string
+	rules       []relabelRule
+}
+
+// newRegistryTransform validates and compiles opts, returning nil if there is
+// nothing to apply
+func newRegistryTransform(opts RegistryOptions) (*registryTransform, error) {
+	if opts.Namespace == "" && len(opts.ConstLabels) == 0 && len(opts.Relabel) == 0 {
+		return nil, nil
+	}
+
+	if opts.Namespace != "" && !labelNamePattern.MatchString(opts.Namespace) {
+		return nil, fmt.Errorf("invalid namespace %q", opts.Namespace)
+	}
+
+	for name := range opts.ConstLabels {
+		if !labelNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid const label name %q", name)
+		}
+	}
+
+	t := &registryTransform{
+		namespace:   opts.Namespace,
+		constLabels: maps.Clone(opts.ConstLabels),
+	}
+
+	// Rules by the literal label name they rename to
+	targets := make(map[string]int)
+
+	for i, rule := range opts.Relabel {
+		if rule.Action != RelabelDrop && rule.Action != RelabelRename {
+			return nil, fmt.Errorf("relabel rule %d: invalid action %q", i, rule.Action)
+		}
+
+		if rule.Action == RelabelRename && rule.Replacement == "" {
+			return nil, fmt.Errorf("relabel rule %d: replacement is required to rename", i)
+		}
+
+		// Replacements with capture group references can only be checked
+		// once the label name is known, when they are applied
+		if rule.Action == RelabelRename && !strings.Contains(rule.Replacement, "$") {
+			if err := validateRenameTarget(rule.Replacement, targets, opts.ConstLabels); err != nil {
+				return nil, fmt.Errorf("relabel rule %d: %w", i, err)
+			}
+
+			targets[rule.Replacement] = i
+		}
+
+		// Anchor the regex so it must match the whole label name
+		regex, err := regexp.Compile("^(?:" + rule.Regex + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("relabel rule %d: invalid regex: %w", i, err)
+		}
+
+		t.rules = append(t.rules, relabelRule{action: rule.Action, regex: regex, replacement: rule.Replacement})
+	}
+
+	return t, nil
+}
+
+// validateRenameTarget checks that a literal rename target is a valid label
+// name that no const label or earlier rule already uses
+func validateRenameTarget(target string, targets map[string]int, constLabels map[string]string) error {
+	if !labelNamePattern.MatchString(target) {
+		return fmt.Errorf("invalid replacement label name %q", target)
+	}
+
+	if _, ok := constLabels[target]; ok {
+		return fmt.Errorf("replacement %q is already a const label", target)
+	}
+
+	if previous, ok := targets[target]; ok {
+		return fmt.Errorf("replacement %q is already the target of rule %d", target, previous)
+	}
+
+	return nil
+}
+
+// SetOptions changes the options applied to every gathered metric. It can
+// be called at any time, such as after a configuration reload; passing the
+// zero RegistryOptions turns them off.
+func (r *Registry) SetOptions(opts RegistryOptions) error {
+	transform, err := newRegistryTransform(opts)
+	if err != nil {
+		return err
+	}
+
+	r.transformMu.Lock()
+	r.transform = transform
+	r.transformMu.Unlock()
+
+	return nil
+}
+
+// Gatherer returns the gatherer that should be exposed on /metrics. It
+// gathers the underlying registry and the scrape collectors, and applies the
+// registry's options and cardinality limits.
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	return r.GathererWithContext(context.Background())
+}
+
+// GathererWithContext is Gatherer with scrape collectors run with ctx, so
+// they stop at the scrape's deadline
+func (r *Registry) GathererWithContext(ctx context.Context) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := r.gather(ctx)
+
+		transform := r.currentTransform()
+		if transform != nil {
+			transform.apply(families)
+		}
+
+		if limiter := r.currentLimiter(); limiter != nil {
+			// The dropped series counter is never limited, so it can't hide
+			// its own drops
+			exempt := r.prefixed("series_dropped_total")
+			if transform != nil {
+				exempt = transform.metricName(exempt)
+			}
+
+			for metric, count := range limiter.apply(families, exempt) {
+				r.seriesDroppedCounter().WithLabelValues(metric).Add(float64(count))
+				limiter.warn(metric, count)
+			}
+		}
+
+		return families, err
+	})
+}
+
+// currentTransform returns the compiled options, or nil if there are none
+func (r *Registry) currentTransform() *registryTransform {
+	r.transformMu.RLock()
+	defer r.transformMu.RUnlock()
+
+	return r.transform
+}
+
+// apply rewrites families in place. The registry builds new families on
+// every Gather, so nothing else sees the changes.
+func (t *registryTransform) apply(families []*dto.MetricFamily) {
+	for _, family := range families {
+		if t.namespace != "" {
+			name := t.metricName(family.GetName())
+			family.Name = &name
+		}
+
+		seen := make(map[string]bool, len(family.Metric))
+		metrics := family.Metric[:0]
+
+		for _, metric := range family.Metric {
+			labels := make([]label, 0, len(metric.Label))
+			for _, pair := range metric.Label {
+				labels = append(labels, label{name: pair.GetName(), value: pair.GetValue()})
+			}
+
+			metric.Label = labelPairs(t.labels(labels))
+
+			// Dropping a label can leave duplicate series, which would make
+			// the scrape fail
+			key := seriesKey(metric.Label)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			metrics = append(metrics, metric)
+		}
+
+		family.Metric = metrics
+	}
+}
+
+// metricName returns name with the namespace applied
+func (t *registryTransform) metricName(name string) string {
+	if t.namespace == "" {
+		return name
+	}
+
+	return t.namespace + "_" + name
+}
+
+// label is a label name and value
+type label struct {
+	name  string
+	value string
+}
+
+// labels returns labels with the const labels a metric doesn't have
+// appended and the relabel rules applied, keeping their order
+func (t *registryTransform) labels(labels []label) []label {
+	result := slices.Clone(labels)
+
+	for _, name := range slices.Sorted(maps.Keys(t.constLabels)) {
+		if !slices.ContainsFunc(result, func(l label) bool { return l.name == name }) {
+			result = append(result, label{name: name, value: t.constLabels[name]})
+		}
+	}
+
+	for _, rule := range t.rules {
+		result = rule.apply(result)
+	}
+
+	return result
+}
+
+// apply applies the rule to labels in place. Matching labels are handled in
+// name order, so a collision resolves the same way whatever order the
+// labels are in.
+func (r relabelRule) apply(labels []label) []label {
+	names := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		names[l.name] = true
+	}
+
+	order := make([]int, len(labels))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.Slice(order, func(i, j int) bool { return labels[order[i]].name < labels[order[j]].name })
+
+	for _, i := range order {
+		name := labels[i].name
+		if !r.regex.MatchString(name) {
+			continue
+		}
+
+		if r.action == RelabelDrop {
+			delete(names, name)
+			labels[i].name = ""
+
+			continue
+		}
+
+		target := r.regex.ReplaceAllString(name, r.replacement)
+		if target == name || names[target] || !labelNamePattern.MatchString(target) {
+			continue
+		}
+
+		delete(names, name)
+		names[target] = true
+		labels[i].name = target
+	}
+
+	return slices.DeleteFunc(labels, func(l label) bool { return l.name == "" })
+}
+
+// labelNames returns the label names a metric with names is exposed with,
+// in the order they were declared
+func (t *registryTransform) labelNames(names []string) []string {
+	labels := make([]label, len(names))
+	for i, name := range names {
+		labels[i] = label{name: name}
+	}
+
+	result := make([]string, 0, len(names))
+	for _, l := range t.labels(labels) {
+		result = append(result, l.name)
+	}
+
+	return result
+}
+
+// labelPairs returns labels as label pairs sorted by name
+func labelPairs(labels []label) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+
+	for _, l := range labels {
+		pairs = append(pairs, labelPair(l.name, l.value))
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].GetName() < pairs[j].GetName() })
+
+	return pairs
+}
+
+// seriesKey identifies a series by its sorted label pairs
+func seriesKey(pairs []*dto.LabelPair) string {
+	var key strings.Builder
+
+	for _, pair := range pairs {
+		key.WriteString(pair.GetName())
+		key.WriteByte(0xff)
+		key.WriteString(pair.GetValue())
+		key.WriteByte(0xff)
+	}
+
+	return key.String()
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TestRegistryOptions asserts the namespace, const labels and relabel rules
+// are applied to collectors registered directly on the registry.
+func TestRegistryOptions(t *testing.T) {
+	registry, err := NewRegistryWithOptions("options_test_info", RegistryOptions{
+		Namespace:   "acme",
+		ConstLabels: map[string]string{"site": "london", "host": "ignored"},
+		Relabel: []RelabelRule{
+			{Action: RelabelDrop, Regex: "pod"},
+			{Action: RelabelRename, Regex: "k8s_(.*)", Replacement: "$1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "Requests",
+	}, []string{"host", "pod", "k8s_namespace"})
+	registry.GetRegistry().MustRegister(requests)
+
+	requests.WithLabelValues("a", "pod-1", "default").Inc()
+	requests.WithLabelValues("a", "pod-2", "default").Inc()
+
+	families, err := registry.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	family := findFamily(families, "acme_requests_total")
+	if family == nil {
+		t.Fatal("expected the metric to be exposed as acme_requests_total")
+	}
+
+	// Dropping pod leaves two identical series, only one of which is kept
+	if len(family.Metric) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(family.Metric))
+	}
+
+	got := make(map[string]string)
+	for _, pair := range family.Metric[0].Label {
+		got[pair.GetName()] = pair.GetValue()
+	}
+
+	want := map[string]string{"host": "a", "namespace": "default", "site": "london"}
+	if len(got) != len(want) {
+		t.Errorf("want labels %v, got %v", want, got)
+	}
+
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("want labels %v, got %v", want, got)
+		}
+	}
+
+	if info := registry.GetMetricsInfo(); info[0].Name != "acme_options_test_info" {
+		t.Errorf("expected the metric info to use the namespace, got %s", info[0].Name)
+	}
+}
+
+func TestRegistryOptions_Invalid(t *testing.T) {
+	registry := NewRegistry("options_test_info")
+
+	for _, opts := range []RegistryOptions{
+		{Namespace: "acme-corp"},
+		{ConstLabels: map[string]string{"1site": "london"}},
+		{Relabel: []RelabelRule{{Action: "keep", Regex: "pod"}}},
+		{Relabel: []RelabelRule{{Action: RelabelRename, Regex: "pod"}}},
+		{Relabel: []RelabelRule{{Action: RelabelDrop, Regex: "("}}},
+		{Relabel: []RelabelRule{{Action: RelabelRename, Regex: "pod", Replacement: "pod-name"}}},
+		{Relabel: []RelabelRule{
+			{Action: RelabelRename, Regex: "pod", Replacement: "instance"},
+			{Action: RelabelRename, Regex: "host", Replacement: "instance"},
+		}},
+		{
+			ConstLabels: map[string]string{"site": "london"},
+			Relabel:     []RelabelRule{{Action: RelabelRename, Regex: "location", Replacement: "site"}},
+		},
+	} {
+		if err := registry.SetOptions(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+// TestRegistryOptions_RenameCollision asserts a rename onto a label the
+// series already has, or to an invalid name, leaves the label as it is.
+func TestRegistryOptions_RenameCollision(t *testing.T) {
+	registry, err := NewRegistryWithOptions("options_test_info", RegistryOptions{
+		Relabel: []RelabelRule{
+			{Action: RelabelRename, Regex: "k8s_(.*)", Replacement: "$1"},
+			{Action: RelabelRename, Regex: "raw_(.*)", Replacement: "${1}-x"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "Requests",
+	}, []string{"namespace", "k8s_namespace", "k8s_pod", "raw_id"})
+	registry.GetRegistry().MustRegister(requests)
+
+	requests.WithLabelValues("app", "default", "pod-1", "1").Inc()
+
+	families, err := registry.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, pair := range findFamily(families, "requests_total").Metric[0].Label {
+		got[pair.GetName()] = pair.GetValue()
+	}
+
+	want := map[string]string{"namespace": "app", "k8s_namespace": "default", "pod": "pod-1", "raw_id": "1"}
+	if len(got) != len(want) {
+		t.Errorf("want labels %v, got %v", want, got)
+	}
+
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("want labels %v, got %v", want, got)
+		}
+	}
+}
+
+// TestRegistryOptions_MetricsInfoLabelOrder asserts the UI lists labels in
+// the order they were declared, with const labels after them.
+func TestRegistryOptions_MetricsInfoLabelOrder(t *testing.T) {
+	registry, err := NewRegistryWithOptions("options_test_info", RegistryOptions{
+		ConstLabels: map[string]string{"site": "london"},
+		Relabel:     []RelabelRule{{Action: RelabelRename, Regex: "k8s_(.*)", Replacement: "$1"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+
+	registry.AddMetricInfo("requests_total", "Requests", []string{"zone", "k8s_pod", "code"})
+
+	var labels []string
+
+	for _, info := range registry.GetMetricsInfo() {
+		if info.Name == "requests_total" {
+			labels = info.Labels
+		}
+	}
+
+	want := []string{"zone", "pod", "code", "site"}
+	if !slices.Equal(labels, want) {
+		t.Errorf("want labels %v, got %v", want, labels)
+	}
+}
+
+func findFamily(families []*dto.MetricFamily, name string) *dto.MetricFamily {
+	for _, family := range families {
+		if family.GetName() == name {
+			return family
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Push targets, used as the target label of the push self-metrics
+const (
+	PushTargetPushgateway = "pushgateway"
+	PushTargetRemoteWrite = "remote_write"
+)
+
+// Push retry backoff, doubled after each failed attempt up to the maximum
+const (
+	pushInitialBackoff = time.Second
+	pushMaxBackoff     = 30 * time.Second
+)
+
+// PushOptions configure a Pusher. At least one of Pushgateway and
+// RemoteWrite must be set.
+type PushOptions struct {
+	Interval    time.Duration // Time between pushes
+	Timeout     time.Duration // Timeout for each attempt
+	MaxRetries  int           // Retries after a failed attempt, with exponential backoff
+	Pushgateway *PushgatewayOptions
+	RemoteWrite *RemoteWriteOptions
+}
+
+// PushgatewayOptions configure pushing to a Prometheus Pushgateway. Each
+// push replaces the metrics in the group identified by Job and Grouping.
+type PushgatewayOptions struct {
+	URL      string
+	Job      string
+	Grouping map[string]string
+	Username string
+	Password string
+	Headers  map[string]string
+}
+
+// RemoteWriteOptions configure pushing with the Prometheus remote-write
+// protocol. Every series is sent with a job label and the external labels,
+// unless it already has a label with that name, as a scrape would add them.
+type RemoteWriteOptions struct {
+	URL            string
+	Job            string
+	ExternalLabels map[string]string
+	Username       string
+	Password       string
+	Headers        map[string]string
+}
+
+// PushMetrics holds the self-metrics published for pushes, labelled by
+// target
+type PushMetrics struct {
+	Pushes      *prometheus.CounterVec
+	Failures    *prometheus.CounterVec
+	LastSuccess *prometheus.GaugeVec
+}
+
+// Pusher pushes the registry's metrics on an interval, for exporters that
+// can't be scraped
+type Pusher struct {
+	targets    []pushTarget
+	interval   time.Duration
+	timeout    time.Duration
+	maxRetries int
+	metrics    *PushMetrics
+
+	// mu serialises pushes, so the final push on shutdown doesn't overlap a
+	// scheduled one
+	mu sync.Mutex
+
+	// backoff is the first retry delay, overridden in tests
+	backoff time.Duration
+}
+
+// pushTarget pushes gathered metrics to one destination
+type pushTarget struct {
+	name string
+	push func(ctx context.Context) error
+}
+
+// noRetryError marks a push error that retrying won't fix, such as a
+// rejected request
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string { return e.err.Error() }
+func (e *noRetryError) Unwrap() error { return e.err }
+
+// pushLabels are the labels shared by all push self-metrics
+var pushLabels = []string{"target"}
+
+// NewPusher creates a Pusher for the registry's metrics, as exposed on
+// /metrics. Call Run to push on the interval and Push for a final push.
+func (r *Registry) NewPusher(opts PushOptions) (*Pusher, error) {
+	if opts.Pushgateway == nil && opts.RemoteWrite == nil {
+		return nil, errors.New("no push target is configured")
+	}
+
+	if opts.Interval <= 0 || opts.Timeout <= 0 {
+		return nil, errors.New("push interval and timeout must be positive")
+	}
+
+	p := &Pusher{
+		interval:   opts.Interval,
+		timeout:    opts.Timeout,
+		maxRetries: opts.MaxRetries,
+		metrics:    r.PushMetrics(),
+		backoff:    pushInitialBackoff,
+	}
+
+	client := &http.Client{}
+	gatherer := r.Gatherer()
+
+	if gw := opts.Pushgateway; gw != nil {
+		job := gw.Job
+		if job == "" {
+			return nil, errors.New("pushgateway job is required")
+		}
+
+		pusher := push.New(gw.URL, job).Gatherer(gatherer).Client(client)
+		for name, value := range gw.Grouping {
+			pusher = pusher.Grouping(name, value)
+		}
+
+		if gw.Username != "" {
+			pusher = pusher.BasicAuth(gw.Username, gw.Password)
+		}
+
+		if len(gw.Headers) > 0 {
+			header := make(http.Header, len(gw.Headers))
+			for name, value := range gw.Headers {
+				header.Set(name, value)
+			}
+
+			pusher = pusher.Header(header)
+		}
+
+		if err := pusher.Error(); err != nil {
+			return nil, fmt.Errorf("invalid pushgateway settings: %w", err)
+		}
+
+		p.targets = append(p.targets, pushTarget{name: PushTargetPushgateway, push: pusher.PushContext})
+	}
+
+	if rw := opts.RemoteWrite; rw != nil {
+		if rw.Job == "" {
+			return nil, errors.New("remote write job is required")
+		}
+
+		writer := &remoteWriter{options: *rw, client: client, gatherer: gatherer}
+		p.targets = append(p.targets, pushTarget{name: PushTargetRemoteWrite, push: writer.write})
+	}
+
+	for _, target := range p.targets {
+		p.metrics.Pushes.WithLabelValues(target.name)
+		p.metrics.Failures.WithLabelValues(target.name)
+	}
+
+	return p, nil
+}
+
+// PushMetrics returns the push self-metrics, registering them on first use
+// so that exporters that don't push don't list them.
+func (r *Registry) PushMetrics() *PushMetrics {
+	r.pushMetricsOnce.Do(func() {
+		pm := &PushMetrics{
+			Pushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: r.prefixed("push_total"),
+				Help: "Total number of pushes, including retries",
+			}, pushLabels),
+			Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: r.prefixed("push_failures_total"),
+				Help: "Total number of pushes that failed after all retries",
+			}, pushLabels),
+			LastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: r.prefixed("push_last_success_timestamp_seconds"),
+				Help: "Unix timestamp of the last successful push",
+			}, pushLabels),
+		}
+
+		r.registry.MustRegister(pm.Pushes, pm.Failures, pm.LastSuccess)
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("push_total"),
+			Help:   "Total number of pushes, including retries",
+			Type:   MetricTypeCounter,
+			Labels: pushLabels,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("push_failures_total"),
+			Help:   "Total number of pushes that failed after all retries",
+			Type:   MetricTypeCounter,
+			Labels: pushLabels,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("push_last_success_timestamp_seconds"),
+			Help:   "Unix timestamp of the last successful push",
+			Type:   MetricTypeGauge,
+			Labels: pushLabels,
+		})
+
+		r.pushMetrics = pm
+	})
+
+	return r.pushMetrics
+}
+
+// Run pushes on the interval until ctx is done. Failures are logged and
+// counted; the next push tries again.
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = p.Push(ctx)
+		}
+	}
+}
+
+// Push pushes once to every target, retrying failed attempts, and returns
+// the errors of the targets that still failed
+func (p *Pusher) Push(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+
+	for _, target := range p.targets {
+		if err := p.pushTo(ctx, target); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// pushTo pushes to target, retrying with exponential backoff. A push cut
+// short by ctx isn't counted as a failure.
+func (p *Pusher) pushTo(ctx context.Context, target pushTarget) error {
+	backoff := p.backoff
+
+	for attempt := 0; ; attempt++ {
+		p.metrics.Pushes.WithLabelValues(target.name).Inc()
+
+		attemptCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		err := target.push(attemptCtx)
+
+		cancel()
+
+		if err == nil {
+			p.metrics.LastSuccess.WithLabelValues(target.name).SetToCurrentTime()
+
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		var noRetry *noRetryError
+		if attempt >= p.maxRetries || errors.As(err, &noRetry) {
+			p.metrics.Failures.WithLabelValues(target.name).Inc()
+
+			slog.Error("Failed to push metrics",
+				"target", target.name,
+				"attempts", attempt+1,
+				"error", err,
+			)
+
+			return err
+		}
+
+		slog.Debug("Push failed, retrying",
+			"target", target.name,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, pushMaxBackoff)
+	}
+}
@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TestPusher_RemoteWrite asserts metrics are sent as a remote-write request
+// with the job and external labels, and a failed attempt is retried.
+func TestPusher_RemoteWrite(t *testing.T) {
+	registry := NewRegistry("push_test_info")
+
+	temperature, err := registry.NewGaugeVec(prometheus.GaugeOpts{Name: "temperature", Help: "Temperature"}, []string{"room"})
+	if err != nil {
+		t.Fatalf("NewGaugeVec: %v", err)
+	}
+
+	temperature.WithLabelValues("kitchen").Set(21.5)
+
+	var (
+		attempts atomic.Int32
+		series   map[string]float64
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+
+		compressed, _ := io.ReadAll(r.Body)
+
+		body, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Errorf("snappy: %v", err)
+		}
+
+		series = decodeWriteRequest(t, body)
+	}))
+	defer server.Close()
+
+	pusher, err := registry.NewPusher(PushOptions{
+		Interval:   time.Minute,
+		Timeout:    time.Second,
+		MaxRetries: 1,
+		RemoteWrite: &RemoteWriteOptions{
+			URL:            server.URL,
+			Job:            "batch",
+			ExternalLabels: map[string]string{"cluster": "prod-1", "room": "ignored"},
+			Headers:        map[string]string{"Authorization": "Bearer secret"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPusher: %v", err)
+	}
+
+	pusher.backoff = time.Millisecond
+
+	if err := pusher.Push(context.Background()); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// The series' own room label wins over the external label
+	if got := series[`__name__="temperature",cluster="prod-1",job="batch",room="kitchen"`]; got != 21.5 {
+		t.Errorf("expected the gauge to be sent, got %v", series)
+	}
+
+	if _, ok := series[`__name__="push_test_push_total",cluster="prod-1",job="batch",room="ignored",target="remote_write"`]; !ok {
+		t.Errorf("expected the push self-metrics to be sent, got %v", series)
+	}
+
+	if got := testutil.ToFloat64(registry.PushMetrics().Pushes.WithLabelValues(PushTargetRemoteWrite)); got != 2 {
+		t.Errorf("expected 2 attempts, got %v", got)
+	}
+}
+
+// TestPusher_RejectedIsNotRetried asserts a request the endpoint rejects is
+// counted as a failure without retrying.
+func TestPusher_RejectedIsNotRetried(t *testing.T) {
+	registry := NewRegistry("push_test_info")
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	pusher, err := registry.NewPusher(PushOptions{
+		Interval:    time.Minute,
+		Timeout:     time.Second,
+		MaxRetries:  3,
+		RemoteWrite: &RemoteWriteOptions{URL: server.URL, Job: "batch"},
+	})
+	if err != nil {
+		t.Fatalf("NewPusher: %v", err)
+	}
+
+	err = pusher.Push(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "out of order sample") {
+		t.Errorf("expected the rejection to be returned, got %v", err)
+	}
+
+	if attempts.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts.Load())
+	}
+
+	if got := testutil.ToFloat64(registry.PushMetrics().Failures.WithLabelValues(PushTargetRemoteWrite)); got != 1 {
+		t.Errorf("expected 1 failure, got %v", got)
+	}
+}
+
+// TestPusher_Pushgateway asserts metrics are pushed to the group for the
+// job and grouping labels.
+func TestPusher_Pushgateway(t *testing.T) {
+	registry := NewRegistry("push_test_info")
+
+	var path, body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, body = r.Method+" "+r.URL.Path, string(data)
+	}))
+	defer server.Close()
+
+	pusher, err := registry.NewPusher(PushOptions{
+		Interval: time.Minute,
+		Timeout:  time.Second,
+		Pushgateway: &PushgatewayOptions{
+			URL:      server.URL,
+			Job:      "backup",
+			Grouping: map[string]string{"instance": "nas"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPusher: %v", err)
+	}
+
+	if err := pusher.Push(context.Background()); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	if path != "PUT /metrics/job/backup/instance/nas" {
+		t.Errorf("unexpected request %q", path)
+	}
+
+	if !strings.Contains(body, "go_goroutines") {
+		t.Error("expected the registry's metrics to be pushed")
+	}
+}
+
+// decodeWriteRequest decodes a remote-write request into sample values
+// keyed by their labels
+func decodeWriteRequest(t *testing.T, body []byte) map[string]float64 {
+	t.Helper()
+
+	series := make(map[string]float64)
+
+	forEachField(t, body, func(_ protowire.Number, timeSeries []byte) {
+		var (
+			labels []string
+			value  float64
+		)
+
+		forEachField(t, timeSeries, func(num protowire.Number, message []byte) {
+			if num == 2 {
+				bits, _ := protowire.ConsumeFixed64(message[1:])
+				value = math.Float64frombits(bits)
+
+				return
+			}
+
+			var pair [2]string
+
+			forEachField(t, message, func(num protowire.Number, value []byte) {
+				pair[num-1] = string(value)
+			})
+
+			labels = append(labels, pair[0]+`="`+pair[1]+`"`)
+		})
+
+		series[strings.Join(labels, ",")] = value
+	})
+
+	return series
+}
+
+// forEachField calls fn with each length-delimited field of message,
+// skipping the others
+func forEachField(t *testing.T, message []byte, fn func(protowire.Number, []byte)) {
+	t.Helper()
+
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+
+		message = message[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, message)
+			message = message[n:]
+
+			continue
+		}
+
+		value, n := protowire.ConsumeBytes(message)
+		if n < 0 {
+			t.Fatalf("invalid field: %v", protowire.ParseError(n))
+		}
+
+		fn(num, value)
+
+		message = message[n:]
+	}
+}
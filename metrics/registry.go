@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"strings"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -14,8 +17,50 @@ type Registry struct {
 	// Version info metric (standard across all exporters)
 	VersionInfo *prometheus.GaugeVec
 
+	// Prefix for the exporter's own self-metrics, derived from the info
+	// metric name (e.g. "my_exporter_info" -> "my_exporter")
+	prefix string
+
 	// Metric information for UI
-	metricInfo []MetricInfo
+	metricInfo   []MetricInfo
+	metricInfoMu sync.RWMutex
+
+	// Collector self-metrics, created on first use
+	collectorMetrics     *CollectorMetrics
+	collectorMetricsOnce sync.Once
+
+	// Config reload self-metrics, created on first use
+	reloadMetrics     *ReloadMetrics
+	reloadMetricsOnce sync.Once
+
+	// Push self-metrics, created on first use
+	pushMetrics     *PushMetrics
+	pushMetricsOnce sync.Once
+
+	// HTTP authentication self-metrics, created on first use
+	authMetrics     *AuthMetrics
+	authMetricsOnce sync.Once
+
+	// Options and cardinality limits applied at gather time, nil when there
+	// are none
+	transform   *registryTransform
+	limiter     *cardinalityLimiter
+	transformMu sync.RWMutex
+
+	// Counter of series dropped by the cardinality limits, created when
+	// limits are first set
+	seriesDropped     *prometheus.CounterVec
+	seriesDroppedOnce sync.Once
+
+	// Collectors run each time the registry is gathered
+	scrapeCollectors   []*ScrapeCollector
+	scrapeCollectorsMu sync.RWMutex
+
+	// Descriptions of the scrape-time collector self-metrics, created with
+	// the first scrape collector
+	scrapeUpDesc       *prometheus.Desc
+	scrapeDurationDesc *prometheus.Desc
+	scrapeDescsOnce    sync.Once
 }
 
 // NewRegistry creates a new metrics registry
@@ -25,6 +70,7 @@ func NewRegistry(exporterInfoName string) *Registry {
 
 	r := &Registry{
 		registry: registry,
+		prefix:   strings.TrimSuffix(exporterInfoName, "_info"),
 		VersionInfo: factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: exporterInfoName,
@@ -39,32 +85,78 @@ func NewRegistry(exporterInfoName string) *Registry {
 	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
 	// Add version info metric to the UI info
-	r.addMetricInfo(exporterInfoName, "Information about the exporter", []string{"version", "commit", "build_date"})
+	r.addMetricInfo(MetricInfo{
+		Name:   exporterInfoName,
+		Help:   "Information about the exporter",
+		Type:   MetricTypeGauge,
+		Labels: []string{"version", "commit", "build_date"},
+	})
 
 	return r
 }
 
-// AddMetricInfo allows external packages to add metric information
+// NewRegistryWithOptions creates a new metrics registry that applies opts to
+// every metric it exposes
+func NewRegistryWithOptions(exporterInfoName string, opts RegistryOptions) (*Registry, error) {
+	r := NewRegistry(exporterInfoName)
+	if err := r.SetOptions(opts); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// AddMetricInfo allows external packages to add metric information for
+// metrics registered directly on GetRegistry. The NewXVec constructors do
+// this for you.
 func (r *Registry) AddMetricInfo(name, help string, labels []string) {
-	r.addMetricInfo(name, help, labels)
+	r.addMetricInfo(MetricInfo{
+		Name:   name,
+		Help:   help,
+		Labels: labels,
+	})
 }
 
-// GetRegistry returns the underlying Prometheus registry
+// GetRegistry returns the underlying Prometheus registry, for registering
+// collectors. Expose Gatherer rather than this so the registry's options are
+// applied.
 func (r *Registry) GetRegistry() *prometheus.Registry {
 	return r.registry
 }
 
 // GetMetricsInfo returns information about all metrics for the UI
 func (r *Registry) GetMetricsInfo() []MetricInfo {
-	return r.metricInfo
+	r.metricInfoMu.RLock()
+	defer r.metricInfoMu.RUnlock()
+
+	info := make([]MetricInfo, len(r.metricInfo))
+	copy(info, r.metricInfo)
+
+	// Show names and labels as they are exposed
+	if transform := r.currentTransform(); transform != nil {
+		for i := range info {
+			info[i].Name = transform.metricName(info[i].Name)
+			info[i].Labels = transform.labelNames(info[i].Labels)
+		}
+	}
+
+	return info
+}
+
+// Prefix returns the prefix used for the exporter's self-metrics
+func (r *Registry) Prefix() string {
+	return r.prefix
+}
+
+// prefixed returns name prefixed with the exporter's metric prefix
+func (r *Registry) prefixed(name string) string {
+	return r.prefix + "_" + name
 }
 
 // addMetricInfo adds metric information to the registry
-func (r *Registry) addMetricInfo(name, help string, labels []string) {
-	r.metricInfo = append(r.metricInfo, MetricInfo{
-		Name:         name,
-		Help:         help,
-		Labels:       labels,
-		ExampleValue: "",
-	})
+func (r *Registry) addMetricInfo(info MetricInfo) {
+	r.metricInfoMu.Lock()
+	defer r.metricInfoMu.Unlock()
+
+	r.metricInfo = append(r.metricInfo, info)
 }
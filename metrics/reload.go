@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReloadMetrics holds the self-metrics published for configuration reloads
+type ReloadMetrics struct {
+	Failures    prometheus.Counter
+	LastSuccess prometheus.Gauge
+}
+
+// ReloadMetrics returns the configuration reload metrics, registering them
+// on first use so that exporters without reloading don't list them.
+func (r *Registry) ReloadMetrics() *ReloadMetrics {
+	r.reloadMetricsOnce.Do(func() {
+		rm := &ReloadMetrics{
+			Failures: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: r.prefixed("config_reload_failures_total"),
+				Help: "Total number of failed configuration reloads",
+			}),
+			LastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: r.prefixed("config_last_reload_success_timestamp_seconds"),
+				Help: "Unix timestamp of the last successful configuration reload",
+			}),
+		}
+
+		r.registry.MustRegister(rm.Failures, rm.LastSuccess)
+
+		r.addMetricInfo(MetricInfo{
+			Name: r.prefixed("config_reload_failures_total"),
+			Help: "Total number of failed configuration reloads",
+			Type: MetricTypeCounter,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name: r.prefixed("config_last_reload_success_timestamp_seconds"),
+			Help: "Unix timestamp of the last successful configuration reload",
+			Type: MetricTypeGauge,
+		})
+
+		r.reloadMetrics = rm
+	})
+
+	return r.reloadMetrics
+}
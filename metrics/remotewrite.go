@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriter sends gathered metrics with the Prometheus remote-write 1.0
+// protocol: a snappy-compressed protobuf WriteRequest
+type remoteWriter struct {
+	options  RemoteWriteOptions
+	client   *http.Client
+	gatherer prometheus.Gatherer
+}
+
+// remoteWriteSample is one sample of a time series, with its labels
+// including __name__
+type remoteWriteSample struct {
+	labels    []*dto.LabelPair
+	value     float64
+	timestamp int64
+}
+
+// write gathers the metrics and sends them in a single request. Rejected
+// requests aren't retried, except when the endpoint is rate limiting.
+func (w *remoteWriter) write(ctx context.Context) error {
+	families, err := w.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	samples := remoteWriteSamples(families, w.seriesLabels(), time.Now())
+	body := snappy.Encode(nil, encodeWriteRequest(samples))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return &noRetryError{err: err}
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	for name, value := range w.options.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if w.options.Username != "" {
+		req.SetBasicAuth(w.options.Username, w.options.Password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		return nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	err = fmt.Errorf("remote write returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return &noRetryError{err: err}
+	}
+
+	return err
+}
+
+// seriesLabels returns the labels added to every series: the external
+// labels and job
+func (w *remoteWriter) seriesLabels() map[string]string {
+	labels := maps.Clone(w.options.ExternalLabels)
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+
+	labels["job"] = w.options.Job
+
+	return labels
+}
+
+// remoteWriteSamples flattens families into samples the way they appear in
+// the text format: histograms and summaries become their _bucket, _sum and
+// _count series. Each series gets the seriesLabels it doesn't already have,
+// and samples without a timestamp are given now.
+func remoteWriteSamples(families []*dto.MetricFamily, seriesLabels map[string]string, now time.Time) []remoteWriteSample {
+	var samples []remoteWriteSample
+
+	for _, family := range families {
+		name := family.GetName()
+
+		for _, metric := range family.Metric {
+			timestamp := now.UnixMilli()
+			if metric.TimestampMs != nil {
+				timestamp = metric.GetTimestampMs()
+			}
+
+			// The series' own labels take precedence over the added ones
+			shared := make([]*dto.LabelPair, 0, len(metric.Label)+len(seriesLabels))
+			shared = append(shared, metric.Label...)
+
+			for _, label := range slices.Sorted(maps.Keys(seriesLabels)) {
+				if !slices.ContainsFunc(metric.Label, func(pair *dto.LabelPair) bool { return pair.GetName() == label }) {
+					shared = append(shared, labelPair(label, seriesLabels[label]))
+				}
+			}
+
+			add := func(suffix string, value float64, extra ...string) {
+				labels := make([]*dto.LabelPair, 0, len(shared)+2)
+				labels = append(labels, labelPair("__name__", name+suffix))
+				labels = append(labels, shared...)
+
+				for i := 0; i+1 < len(extra); i += 2 {
+					labels = append(labels, labelPair(extra[i], extra[i+1]))
+				}
+
+				sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+				samples = append(samples, remoteWriteSample{labels: labels, value: value, timestamp: timestamp})
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add("", metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add("", metric.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.Quantile {
+					add("", quantile.GetValue(), "quantile", formatLabelFloat(quantile.GetQuantile()))
+				}
+
+				add("_sum", summary.GetSampleSum())
+				add("_count", float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				histogram := metric.GetHistogram()
+
+				hasInf := false
+				for _, bucket := range histogram.Bucket {
+					hasInf = hasInf || math.IsInf(bucket.GetUpperBound(), 1)
+					add("_bucket", float64(bucket.GetCumulativeCount()), "le", formatLabelFloat(bucket.GetUpperBound()))
+				}
+
+				if !hasInf {
+					add("_bucket", float64(histogram.GetSampleCount()), "le", "+Inf")
+				}
+
+				add("_sum", histogram.GetSampleSum())
+				add("_count", float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+
+	return samples
+}
+
+// encodeWriteRequest encodes samples as a remote-write WriteRequest, one
+// time series per sample:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []remoteWriteSample) []byte {
+	var request, series, message []byte
+
+	for _, sample := range samples {
+		series = series[:0]
+
+		for _, label := range sample.labels {
+			message = message[:0]
+			message = protowire.AppendTag(message, 1, protowire.BytesType)
+			message = protowire.AppendString(message, label.GetName())
+			message = protowire.AppendTag(message, 2, protowire.BytesType)
+			message = protowire.AppendString(message, label.GetValue())
+
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, message)
+		}
+
+		message = message[:0]
+		message = protowire.AppendTag(message, 1, protowire.Fixed64Type)
+		message = protowire.AppendFixed64(message, math.Float64bits(sample.value))
+		message = protowire.AppendTag(message, 2, protowire.VarintType)
+		message = protowire.AppendVarint(message, uint64(sample.timestamp)) //nolint:gosec // int64 is encoded as its two's complement
+
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, message)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, series)
+	}
+
+	return request
+}
+
+// labelPair returns a label pair for name and value
+func labelPair(name, value string) *dto.LabelPair {
+	return &dto.LabelPair{Name: &name, Value: &value}
+}
+
+// formatLabelFloat formats a bucket bound or quantile as the text format
+// does
+func formatLabelFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultScrapeTimeout bounds a scrape-time collection when the scrape has no
+// deadline of its own. It matches Prometheus' default scrape timeout.
+const DefaultScrapeTimeout = 10 * time.Second
+
+// ScrapeFunc collects metrics when /metrics is scraped, sending them on ch.
+// It should return when ctx is done.
+type ScrapeFunc func(ctx context.Context, ch chan<- prometheus.Metric) error
+
+// ScrapeOptions configure a ScrapeCollector
+type ScrapeOptions struct {
+	// Timeout caps each collection. The scrape's own deadline, taken from
+	// Prometheus' scrape timeout header, applies either way; with neither,
+	// DefaultScrapeTimeout is used.
+	Timeout time.Duration
+
+	// MinInterval reuses the last successful result for scrapes within this
+	// long of it, so frequent or concurrent scrapes don't query the target
+	// every time. Zero collects on every scrape.
+	MinInterval time.Duration
+}
+
+// ScrapeCollector runs a ScrapeFunc each time the registry is gathered, so
+// scrapes see fresh values rather than the result of the last scheduled run.
+// Alongside the metrics it sends, it exports whether the collection
+// succeeded and how long it took.
+type ScrapeCollector struct {
+	name         string
+	fn           ScrapeFunc
+	opts         ScrapeOptions
+	upDesc       *prometheus.Desc
+	durationDesc *prometheus.Desc
+
+	// mu serialises collections so concurrent scrapes share a cached result
+	mu       sync.Mutex
+	cached   []prometheus.Metric
+	cachedAt time.Time
+	duration time.Duration
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// scrapeRun collects a ScrapeCollector with the context of one gather
+type scrapeRun struct {
+	collector *ScrapeCollector
+	ctx       context.Context
+}
+
+// scrapeLabels are the labels of the scrape-time collector self-metrics
+var scrapeLabels = []string{"collector"}
+
+// NewScrapeCollector registers fn to run whenever the registry is gathered.
+// The metrics it sends are exposed as they are; use AddMetricInfo to list
+// them in the UI. It fails if a scrape collector with the same name exists.
+func (r *Registry) NewScrapeCollector(name string, fn ScrapeFunc, opts ScrapeOptions) (*ScrapeCollector, error) {
+	upDesc, durationDesc := r.scrapeDescs()
+
+	c := &ScrapeCollector{
+		name:         name,
+		fn:           fn,
+		opts:         opts,
+		upDesc:       upDesc,
+		durationDesc: durationDesc,
+		now:          time.Now,
+	}
+
+	r.scrapeCollectorsMu.Lock()
+	defer r.scrapeCollectorsMu.Unlock()
+
+	for _, existing := range r.scrapeCollectors {
+		if existing.name == name {
+			return nil, fmt.Errorf("scrape collector %s is already registered", name)
+		}
+	}
+
+	r.scrapeCollectors = append(r.scrapeCollectors, c)
+
+	return c, nil
+}
+
+// scrapeDescs returns the descriptions of the scrape-time collector
+// self-metrics, adding them to the UI on first use
+func (r *Registry) scrapeDescs() (*prometheus.Desc, *prometheus.Desc) {
+	r.scrapeDescsOnce.Do(func() {
+		r.scrapeUpDesc = prometheus.NewDesc(
+			r.prefixed("scrape_collector_up"),
+			"Whether the last scrape-time collection succeeded",
+			scrapeLabels, nil,
+		)
+		r.scrapeDurationDesc = prometheus.NewDesc(
+			r.prefixed("scrape_collector_duration_seconds"),
+			"Duration of the last scrape-time collection in seconds",
+			scrapeLabels, nil,
+		)
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("scrape_collector_up"),
+			Help:   "Whether the last scrape-time collection succeeded",
+			Type:   MetricTypeGauge,
+			Labels: scrapeLabels,
+		})
+
+		r.addMetricInfo(MetricInfo{
+			Name:   r.prefixed("scrape_collector_duration_seconds"),
+			Help:   "Duration of the last scrape-time collection in seconds",
+			Type:   MetricTypeGauge,
+			Labels: scrapeLabels,
+		})
+	})
+
+	return r.scrapeUpDesc, r.scrapeDurationDesc
+}
+
+// gather gathers the underlying registry together with the scrape-time
+// collectors, which run with ctx
+func (r *Registry) gather(ctx context.Context) ([]*dto.MetricFamily, error) {
+	r.scrapeCollectorsMu.RLock()
+	collectors := r.scrapeCollectors
+	r.scrapeCollectorsMu.RUnlock()
+
+	if len(collectors) == 0 {
+		return r.registry.Gather()
+	}
+
+	// Collect has no context, so the collectors are registered for this
+	// gather only, each carrying its context
+	scrapes := prometheus.NewRegistry()
+	for _, c := range collectors {
+		if err := scrapes.Register(scrapeRun{collector: c, ctx: ctx}); err != nil {
+			return nil, fmt.Errorf("failed to register scrape collector %s: %w", c.name, err)
+		}
+	}
+
+	return prometheus.Gatherers{r.registry, scrapes}.Gather()
+}
+
+// Describe implements prometheus.Collector. It sends nothing, as the
+// metrics a ScrapeFunc sends aren't known in advance.
+func (c *ScrapeCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector, collecting without a scrape
+// deadline
+func (c *ScrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext collects, or reuses a result within MinInterval, and sends
+// the metrics followed by the self-metrics on ch. A failed collection sends
+// only the self-metrics, and returns the error.
+func (c *ScrapeCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+
+	if c.cachedAt.IsZero() || c.opts.MinInterval <= 0 || c.now().Sub(c.cachedAt) >= c.opts.MinInterval {
+		err = c.collect(ctx)
+	}
+
+	up := 1.0
+	if err != nil {
+		up = 0
+
+		slog.Warn("Scrape-time collection failed",
+			"collector", c.name,
+			"duration", c.duration,
+			"error", err,
+		)
+	} else {
+		for _, metric := range c.cached {
+			ch <- metric
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, up, c.name)
+	ch <- prometheus.MustNewConstMetric(c.durationDesc, prometheus.GaugeValue, c.duration.Seconds(), c.name)
+
+	return err
+}
+
+// collect runs the ScrapeFunc, caching its metrics if it succeeds. It gives
+// up when ctx is done even if the ScrapeFunc doesn't. Callers must hold mu.
+func (c *ScrapeCollector) collect(ctx context.Context) error {
+	timeout := c.opts.Timeout
+	if _, ok := ctx.Deadline(); !ok && timeout <= 0 {
+		timeout = DefaultScrapeTimeout
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := c.now()
+	defer func() { c.duration = c.now().Sub(start) }()
+
+	ch := make(chan prometheus.Metric)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- safeScrape(ctx, c.fn, ch)
+		close(ch)
+	}()
+
+	var metrics []prometheus.Metric
+
+	for {
+		select {
+		case metric, ok := <-ch:
+			if !ok {
+				if err := <-errc; err != nil {
+					return err
+				}
+
+				c.cached, c.cachedAt = metrics, c.now()
+
+				return nil
+			}
+
+			metrics = append(metrics, metric)
+		case <-ctx.Done():
+			// Let the ScrapeFunc finish in the background
+			go drain(ch)
+
+			return fmt.Errorf("collection timed out: %w", ctx.Err())
+		}
+	}
+}
+
+// drain discards the rest of ch until it is closed
+func drain(ch <-chan prometheus.Metric) {
+	for range ch {
+		continue
+	}
+}
+
+// safeScrape calls fn and converts a panic into an error
+func safeScrape(ctx context.Context, fn ScrapeFunc, ch chan<- prometheus.Metric) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scrape collector panicked: %v", r)
+		}
+	}()
+
+	return fn(ctx, ch)
+}
+
+// Describe implements prometheus.Collector
+func (s scrapeRun) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector. Errors are reported through the
+// up metric rather than failing the whole scrape.
+func (s scrapeRun) Collect(ch chan<- prometheus.Metric) {
+	_ = s.collector.CollectContext(s.ctx, ch)
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TestScrapeCollector asserts each gather collects fresh values, reuses
+// them within MinInterval and reports failures through the up metric.
+func TestScrapeCollector(t *testing.T) {
+	registry := NewRegistry("scrape_test_info")
+	desc := prometheus.NewDesc("target_value", "Value", nil, nil)
+
+	var (
+		calls int
+		fail  bool
+	)
+
+	collector, err := registry.NewScrapeCollector("target", func(_ context.Context, ch chan<- prometheus.Metric) error {
+		calls++
+
+		if fail {
+			return errors.New("target unreachable")
+		}
+
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(calls))
+
+		return nil
+	}, ScrapeOptions{MinInterval: time.Minute})
+	if err != nil {
+		t.Fatalf("NewScrapeCollector: %v", err)
+	}
+
+	now := time.Unix(1000, 0)
+	collector.now = func() time.Time { return now }
+
+	families := gather(t, registry)
+	if got := gaugeValue(families, "target_value"); got != 1 {
+		t.Errorf("expected the first value, got %v", got)
+	}
+
+	if got := gaugeValue(families, "scrape_test_scrape_collector_up"); got != 1 {
+		t.Errorf("expected up to be 1, got %v", got)
+	}
+
+	now = now.Add(30 * time.Second)
+
+	if got := gaugeValue(gather(t, registry), "target_value"); got != 1 || calls != 1 {
+		t.Errorf("expected the cached value within MinInterval, got %v after %d calls", got, calls)
+	}
+
+	now = now.Add(time.Minute)
+	fail = true
+
+	families = gather(t, registry)
+	if findFamily(families, "target_value") != nil {
+		t.Error("expected no values from a failed collection")
+	}
+
+	if got := gaugeValue(families, "scrape_test_scrape_collector_up"); got != 0 {
+		t.Errorf("expected up to be 0, got %v", got)
+	}
+
+	if _, err := registry.NewScrapeCollector("target", nil, ScrapeOptions{}); err == nil {
+		t.Error("expected an error for a duplicate name")
+	}
+}
+
+// TestScrapeCollector_Deadline asserts a collection that ignores its context
+// is abandoned at the gather's deadline.
+func TestScrapeCollector_Deadline(t *testing.T) {
+	registry := NewRegistry("scrape_test_info")
+	release := make(chan struct{})
+
+	defer close(release)
+
+	_, err := registry.NewScrapeCollector("slow", func(_ context.Context, _ chan<- prometheus.Metric) error {
+		<-release
+		return nil
+	}, ScrapeOptions{})
+	if err != nil {
+		t.Fatalf("NewScrapeCollector: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	families, err := registry.GathererWithContext(ctx).Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	if got := gaugeValue(families, "scrape_test_scrape_collector_up"); got != 0 {
+		t.Errorf("expected up to be 0 after the deadline, got %v", got)
+	}
+}
+
+// gaugeValue returns the value of the first series of the named gauge, or -1
+// if there is none
+func gaugeValue(families []*dto.MetricFamily, name string) float64 {
+	family := findFamily(families, name)
+	if family == nil || len(family.Metric) == 0 {
+		return -1
+	}
+
+	return family.Metric[0].GetGauge().GetValue()
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewCounterVec creates a counter, registers it and adds it to the metric
+// list shown in the UI. It fails if a metric with the same name exists.
+func (r *Registry) NewCounterVec(opts prometheus.CounterOpts, labels []string) (*prometheus.CounterVec, error) {
+	vec := prometheus.NewCounterVec(opts, labels)
+
+	info := MetricInfo{
+		Name:   prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		Help:   opts.Help,
+		Type:   MetricTypeCounter,
+		Labels: labels,
+	}
+	if err := r.register(info, vec); err != nil {
+		return nil, err
+	}
+
+	return vec, nil
+}
+
+// NewGaugeVec creates a gauge, registers it and adds it to the metric list
+// shown in the UI. It fails if a metric with the same name exists.
+func (r *Registry) NewGaugeVec(opts prometheus.GaugeOpts, labels []string) (*prometheus.GaugeVec, error) {
+	vec := prometheus.NewGaugeVec(opts, labels)
+
+	info := MetricInfo{
+		Name:   prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		Help:   opts.Help,
+		Type:   MetricTypeGauge,
+		Labels: labels,
+	}
+	if err := r.register(info, vec); err != nil {
+		return nil, err
+	}
+
+	return vec, nil
+}
+
+// NewHistogramVec creates a histogram, registers it and adds it to the
+// metric list shown in the UI, with its buckets. It fails if a metric with
+// the same name exists.
+func (r *Registry) NewHistogramVec(opts prometheus.HistogramOpts, labels []string) (*prometheus.HistogramVec, error) {
+	// Use the default buckets when none are given, including for an empty
+	// slice, which would otherwise leave only the +Inf bucket
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = prometheus.DefBuckets
+	}
+
+	vec := prometheus.NewHistogramVec(opts, labels)
+
+	info := MetricInfo{
+		Name:    prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		Help:    opts.Help,
+		Type:    MetricTypeHistogram,
+		Labels:  labels,
+		Buckets: opts.Buckets,
+	}
+	if err := r.register(info, vec); err != nil {
+		return nil, err
+	}
+
+	return vec, nil
+}
+
+// NewSummaryVec creates a summary, registers it and adds it to the metric
+// list shown in the UI. It fails if a metric with the same name exists.
+func (r *Registry) NewSummaryVec(opts prometheus.SummaryOpts, labels []string) (*prometheus.SummaryVec, error) {
+	vec := prometheus.NewSummaryVec(opts, labels)
+
+	info := MetricInfo{
+		Name:   prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		Help:   opts.Help,
+		Type:   MetricTypeSummary,
+		Labels: labels,
+	}
+	if err := r.register(info, vec); err != nil {
+		return nil, err
+	}
+
+	return vec, nil
+}
+
+// register registers collector and records info, unless a metric with the
+// same name is already listed or registered
+func (r *Registry) register(info MetricInfo, collector prometheus.Collector) error {
+	r.metricInfoMu.Lock()
+	defer r.metricInfoMu.Unlock()
+
+	for _, existing := range r.metricInfo {
+		if existing.Name == info.Name {
+			return fmt.Errorf("metric %s is already registered", info.Name)
+		}
+	}
+
+	if err := r.registry.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			return fmt.Errorf("metric %s is already registered", info.Name)
+		}
+
+		return fmt.Errorf("failed to register metric %s: %w", info.Name, err)
+	}
+
+	r.metricInfo = append(r.metricInfo, info)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestRegistry_NewHistogramVec asserts the typed constructors register the
+// metric and list it with its type and buckets.
+func TestRegistry_NewHistogramVec(t *testing.T) {
+	registry := NewRegistry("vec_test_info")
+
+	latency, err := registry.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vec",
+		Name:      "latency_seconds",
+		Help:      "Request latency",
+		Buckets:   []float64{0.1, 1},
+	}, []string{"endpoint"})
+	if err != nil {
+		t.Fatalf("NewHistogramVec: %v", err)
+	}
+
+	latency.WithLabelValues("/").Observe(0.5)
+
+	families, err := registry.Gatherer().Gather()
+	if err != nil || findFamily(families, "vec_latency_seconds") == nil {
+		t.Fatalf("expected the histogram to be registered, got %v", err)
+	}
+
+	var info *MetricInfo
+
+	for _, metric := range registry.GetMetricsInfo() {
+		if metric.Name == "vec_latency_seconds" {
+			info = &metric
+		}
+	}
+
+	if info == nil || info.Type != MetricTypeHistogram || len(info.Buckets) != 2 || info.Labels[0] != "endpoint" {
+		t.Errorf("unexpected metric info: %+v", info)
+	}
+}
+
+// TestRegistry_NewHistogramVecDefaultBuckets asserts an empty bucket slice
+// falls back to the default buckets, as a nil one does.
+func TestRegistry_NewHistogramVecDefaultBuckets(t *testing.T) {
+	registry := NewRegistry("vec_test_info")
+
+	latency, err := registry.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "latency_seconds",
+		Help:    "Request latency",
+		Buckets: []float64{},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewHistogramVec: %v", err)
+	}
+
+	latency.WithLabelValues().Observe(0.5)
+
+	families, err := registry.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	buckets := findFamily(families, "latency_seconds").Metric[0].GetHistogram().GetBucket()
+	if len(buckets) != len(prometheus.DefBuckets) {
+		t.Errorf("expected %d default buckets, got %d", len(prometheus.DefBuckets), len(buckets))
+	}
+
+	if info := registry.GetMetricsInfo(); len(info[len(info)-1].Buckets) != len(prometheus.DefBuckets) {
+		t.Errorf("expected the metric info to list the default buckets, got %v", info[len(info)-1].Buckets)
+	}
+}
+
+// TestRegistry_RejectsDuplicateNames asserts a second metric with the same
+// name is an error, whether it was created by a constructor or registered
+// directly.
+func TestRegistry_RejectsDuplicateNames(t *testing.T) {
+	registry := NewRegistry("vec_test_info")
+
+	if _, err := registry.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests"}, nil); err != nil {
+		t.Fatalf("NewCounterVec: %v", err)
+	}
+
+	_, err := registry.NewGaugeVec(prometheus.GaugeOpts{Name: "requests_total", Help: "Requests"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "metric requests_total is already registered") {
+		t.Errorf("expected a duplicate name error, got %v", err)
+	}
+
+	registry.GetRegistry().MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "direct", Help: "Direct"}))
+
+	if _, err := registry.NewGaugeVec(prometheus.GaugeOpts{Name: "direct", Help: "Direct"}, nil); err == nil {
+		t.Error("expected an error for a metric registered directly")
+	}
+}
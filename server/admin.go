@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/promexporter/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// adminLogLevelPath is the admin endpoint for reading and changing the log
+// level
+const adminLogLevelPath = "/admin/loglevel"
+
+// logLevelRequest is the body accepted by PUT /admin/loglevel
+type logLevelRequest struct {
+	Level string `json:"level"`
+	// Optional duration (e.g. "15m") after which the level reverts
+	Duration string `json:"duration"`
+}
+
+// setupAdminRoutes registers the admin endpoints when an admin token is
+// configured
+func (s *Server) setupAdminRoutes() {
+	if !s.config.GetServer().Admin.IsEnabled() {
+		return
+	}
+
+	s.router.GET(adminLogLevelPath, s.requireAdminToken, s.handleGetLogLevel)
+	s.router.PUT(adminLogLevelPath, s.requireAdminToken, s.handleSetLogLevel)
+}
+
+// requireAdminToken rejects requests without the configured bearer token.
+// The token is read on every request so that a config reload can rotate it.
+func (s *Server) requireAdminToken(c *gin.Context) {
+	token := s.currentConfig().GetServer().Admin.Token.Value()
+	provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	if token == "" || !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+
+		return
+	}
+
+	c.Next()
+}
+
+func (s *Server) handleGetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, logLevelResponse())
+}
+
+func (s *Server) handleSetLogLevel(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	var err error
+
+	if req.Duration != "" {
+		var duration time.Duration
+
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid duration: " + err.Error()})
+			return
+		}
+
+		_, err = logging.SetLevelFor(req.Level, duration)
+	} else {
+		err = logging.SetLevel(req.Level)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.Warn("Log level changed via admin endpoint",
+		"level", req.Level,
+		"duration", req.Duration,
+		"client_ip", c.ClientIP(),
+	)
+
+	c.JSON(http.StatusOK, logLevelResponse())
+}
+
+// logLevelResponse describes the current log level and any pending revert
+func logLevelResponse() gin.H {
+	response := gin.H{
+		"level":     logging.GetLevel(),
+		"revert_at": nil,
+	}
+
+	if revertAt := logging.RevertAt(); !revertAt.IsZero() {
+		response["revert_at"] = revertAt.UTC().Format(time.RFC3339)
+	}
+
+	return response
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/logging"
+	"github.com/d0ugal/promexporter/metrics"
+)
+
+// TestAdminLogLevel_RequiresToken asserts the admin endpoints reject
+// requests without the configured bearer token and change the level of the
+// running process when it is supplied.
+func TestAdminLogLevel_RequiresToken(t *testing.T) {
+	logging.Configure(&logging.Config{Level: "info", Format: "json"})
+	t.Cleanup(func() { _ = logging.SetLevel("info") })
+
+	cfg := &minimalConfig{
+		server: &config.ServerConfig{
+			Host:  "127.0.0.1",
+			Admin: config.AdminConfig{Token: config.NewSensitiveString("s3cret")},
+		},
+	}
+	srv := New(cfg, metrics.NewRegistry("server_test_info"), "test-exporter", nil, nil)
+
+	do := func(method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/loglevel", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	if rec := do(http.MethodPut, "", `{"level":"debug"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: want 401, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPut, "wrong", `{"level":"debug"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: want 401, got %d", rec.Code)
+	}
+
+	if logging.GetLevel() != "info" {
+		t.Fatalf("unauthorised requests must not change the level, got %s", logging.GetLevel())
+	}
+
+	if rec := do(http.MethodPut, "s3cret", `{"level":"verbose"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid level: want 400, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPut, "s3cret", `{"level":"debug","duration":"1h"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid request: want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+
+	if body["level"] != "debug" || body["revert_at"] == nil {
+		t.Errorf("expected debug level with a pending revert, got %v", body)
+	}
+
+	if got := logging.GetLevel(); got != "debug" {
+		t.Errorf("expected process log level to be debug, got %s", got)
+	}
+}
+
+// TestAdminLogLevel_DisabledWithoutToken asserts the admin endpoints are not
+// exposed unless a token is configured.
+func TestAdminLogLevel_DisabledWithoutToken(t *testing.T) {
+	cfg := &minimalConfig{
+		server: &config.ServerConfig{Host: "127.0.0.1"},
+	}
+	srv := New(cfg, metrics.NewRegistry("server_test_info"), "test-exporter", nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("want 404 when no admin token is configured, got %d", rec.Code)
+	}
+}
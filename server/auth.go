@@ -0,0 +1,153 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Reasons recorded in the auth failures metric
+const (
+	authFailureMissing = "missing_credentials"
+	authFailureInvalid = "invalid_credentials"
+)
+
+// maxAuthCacheEntries bounds the number of cached basic auth verifications
+const maxAuthCacheEntries = 1024
+
+// dummyPasswordHash is compared against for unknown usernames. Its cost
+// matches the default that htpasswd and bcrypt tooling use.
+const dummyPasswordHash = "$2a$10$vRKKNokRSAV4Bf.BUSBCmevv/lgvJOioo09B3pr1eA9zjtddEtKB2"
+
+// authCache remembers successful bcrypt verifications. bcrypt is
+// deliberately slow and Prometheus sends the same credentials on every
+// scrape, so each combination is only verified once. Entries are keyed by a
+// hash of the username, password hash and password, so a changed password or
+// a reloaded hash never matches an old entry.
+type authCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]struct{}
+}
+
+func newAuthCache() *authCache {
+	return &authCache{entries: make(map[[sha256.Size]byte]struct{})}
+}
+
+// verify reports whether password matches hash for username
+func (a *authCache) verify(username, hash, password string) bool {
+	key := sha256.Sum256([]byte(username + "\x00" + hash + "\x00" + password))
+
+	a.mu.Lock()
+	_, ok := a.entries[key]
+	a.mu.Unlock()
+
+	if ok {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+
+	a.mu.Lock()
+	if len(a.entries) >= maxAuthCacheEntries {
+		clear(a.entries)
+	}
+
+	a.entries[key] = struct{}{}
+	a.mu.Unlock()
+
+	return true
+}
+
+// authenticate rejects requests without valid credentials when
+// authentication is configured. The settings are read on every request so
+// that a config reload can change them.
+func (s *Server) authenticate(c *gin.Context) {
+	auth := s.currentAuth()
+	if !auth.IsEnabled() || s.isAuthExempt(c.Request, auth) {
+		c.Next()
+		return
+	}
+
+	reason := s.checkCredentials(c.Request, auth)
+	if reason == "" {
+		c.Next()
+		return
+	}
+
+	s.metrics.AuthMetrics().Failures.WithLabelValues(reason).Inc()
+
+	slog.Warn("Rejected unauthenticated request",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"client_ip", c.ClientIP(),
+		"reason", reason,
+	)
+
+	if len(auth.BasicAuthUsers) > 0 {
+		c.Header("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", s.name))
+	} else {
+		c.Header("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q", s.name))
+	}
+
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+}
+
+// checkCredentials returns an empty string if the request carries a valid
+// bearer token or basic auth credentials, otherwise the failure reason
+func (s *Server) checkCredentials(r *http.Request, auth *config.AuthConfig) string {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return authFailureMissing
+	}
+
+	if provided, ok := strings.CutPrefix(header, "Bearer "); ok {
+		for _, token := range auth.BearerTokens {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token.Value())) == 1 {
+				return ""
+			}
+		}
+
+		return authFailureInvalid
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		hash, found := auth.BasicAuthUsers[username]
+		if !found {
+			// Take as long as for a known user, so response times don't
+			// reveal which usernames exist
+			_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+
+			return authFailureInvalid
+		}
+
+		if s.authCache.verify(username, hash.Value(), password) {
+			return ""
+		}
+	}
+
+	return authFailureInvalid
+}
+
+// isAuthExempt returns true for requests that are served without the
+// exporter's authentication: the admin endpoints, which check their own
+// token, and the health endpoints unless configured otherwise. Other routes
+// under /admin, such as custom ones, still require credentials.
+func (s *Server) isAuthExempt(r *http.Request, auth *config.AuthConfig) bool {
+	path := r.URL.Path
+
+	if path == adminLogLevelPath && (r.Method == http.MethodGet || r.Method == http.MethodPut) {
+		return s.currentConfig().GetServer().Admin.IsEnabled()
+	}
+
+	return auth.IsHealthExempt() && (path == "/health" || path == "/ready")
+}
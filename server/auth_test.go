@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestAuthenticate_ProtectsMetrics asserts that /metrics requires valid basic
+// auth or bearer credentials once auth is configured, that failures are
+// counted, and that /health stays open for probes.
+func TestAuthenticate_ProtectsMetrics(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	cfg := &minimalConfig{
+		server: &config.ServerConfig{
+			Host: "127.0.0.1",
+			Auth: config.AuthConfig{
+				BasicAuthUsers: map[string]config.SensitiveString{"prometheus": config.NewSensitiveString(string(hash))},
+				BearerTokens:   []config.SensitiveString{config.NewSensitiveString("scrape-token")},
+			},
+		},
+	}
+	registry := metrics.NewRegistry("server_test_info")
+	srv := New(cfg, registry, "test-exporter", nil, nil)
+
+	do := func(path string, setAuth func(*http.Request)) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if setAuth != nil {
+			setAuth(req)
+		}
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		setAuth func(*http.Request)
+		want    int
+	}{
+		{"no credentials", "/metrics", nil, http.StatusUnauthorized},
+		{"wrong password", "/metrics", func(r *http.Request) { r.SetBasicAuth("prometheus", "wrong") }, http.StatusUnauthorized},
+		{"unknown user", "/metrics", func(r *http.Request) { r.SetBasicAuth("nobody", "hunter2") }, http.StatusUnauthorized},
+		{"wrong token", "/metrics", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"basic auth", "/metrics", func(r *http.Request) { r.SetBasicAuth("prometheus", "hunter2") }, http.StatusOK},
+		{"cached basic auth", "/metrics", func(r *http.Request) { r.SetBasicAuth("prometheus", "hunter2") }, http.StatusOK},
+		{"bearer token", "/metrics", func(r *http.Request) { r.Header.Set("Authorization", "Bearer scrape-token") }, http.StatusOK},
+		{"dashboard", "/", nil, http.StatusUnauthorized},
+		{"health exempt", "/health", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := do(tt.path, tt.setAuth); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	failures := registry.AuthMetrics().Failures
+	if got := testutil.ToFloat64(failures.WithLabelValues(authFailureMissing)); got != 2 {
+		t.Errorf("expected 2 missing credential failures, got %v", got)
+	}
+
+	if got := testutil.ToFloat64(failures.WithLabelValues(authFailureInvalid)); got != 3 {
+		t.Errorf("expected 3 invalid credential failures, got %v", got)
+	}
+}
+
+// TestDummyPasswordHash asserts the hash compared for unknown usernames is a
+// valid bcrypt hash, so the comparison costs as much as a real one.
+func TestDummyPasswordHash(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("expected a bcrypt hash with the default cost, got %d, %v", cost, err)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCardinalityTop is how many metrics /debug/cardinality lists unless
+// the top query parameter says otherwise
+const defaultCardinalityTop = 20
+
+// handleCardinality lists the metrics with the most series, to help find a
+// collector that is labelling with unbounded values
+func (s *Server) handleCardinality(c *gin.Context) {
+	top := defaultCardinalityTop
+
+	if value := c.Query("top"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.String(http.StatusBadRequest, "top must be a positive integer\n")
+
+			return
+		}
+
+		top = parsed
+	}
+
+	counts, err := s.metrics.SeriesCounts()
+	if err != nil {
+		slog.Debug("Failed to gather all metrics for the cardinality page", "error", err)
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count.Series
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	limit := "none"
+	if maxSeries := s.metrics.MaxSeries(); maxSeries > 0 {
+		limit = strconv.Itoa(maxSeries)
+	}
+
+	_, _ = fmt.Fprintf(c.Writer, "%d series in %d metrics (limit: %s)\n\n", total, len(counts), limit)
+
+	w := tabwriter.NewWriter(c.Writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(w, "SERIES\tLIMIT\tDROPPED\t\tMETRIC")
+
+	for _, count := range counts[:min(top, len(counts))] {
+		limit := "-"
+		if count.Limit > 0 {
+			limit = strconv.Itoa(count.Limit)
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%d\t\t%s\n", count.Series, limit, count.Dropped, count.Name)
+	}
+
+	_ = w.Flush()
+}
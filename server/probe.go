@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prober probes a single target for the /probe endpoint, in the style of the
+// blackbox and SNMP exporters
+type Prober interface {
+	// Probe checks target with the module's settings and registers the
+	// resulting metrics on registry, which is created for this probe. It
+	// should return when ctx, which expires with the scrape, is done.
+	Probe(ctx context.Context, target string, module config.ProbeModuleConfig, registry *prometheus.Registry) error
+}
+
+// ProberFunc adapts a function to a Prober
+type ProberFunc func(ctx context.Context, target string, module config.ProbeModuleConfig, registry *prometheus.Registry) error
+
+// Probe calls f
+func (f ProberFunc) Probe(ctx context.Context, target string, module config.ProbeModuleConfig, registry *prometheus.Registry) error {
+	return f(ctx, target, module, registry)
+}
+
+// probeConfigProvider is implemented by configs that embed BaseConfig
+type probeConfigProvider interface {
+	GetProbe() *config.ProbeConfig
+}
+
+// RegisterProber makes prober available to probe modules that name it,
+// replacing any prober already registered with that name. The /probe
+// endpoint is added with the first prober, so it must be registered before
+// the server starts.
+func (s *Server) RegisterProber(name string, prober Prober) error {
+	s.probersMu.Lock()
+	defer s.probersMu.Unlock()
+
+	if s.probers == nil {
+		s.mu.Lock()
+		started := s.server != nil || s.stopped
+		s.mu.Unlock()
+
+		if started {
+			return errors.New("probers must be registered before the server starts")
+		}
+
+		s.router.GET("/probe", s.handleProbe)
+		s.probers = make(map[string]Prober)
+	}
+
+	s.probers[name] = prober
+
+	return nil
+}
+
+// handleProbe probes the target query parameter with the module query
+// parameter's settings and serves the result. The module can be left out
+// when only one is configured.
+func (s *Server) handleProbe(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.String(http.StatusBadRequest, "target parameter is missing\n")
+
+		return
+	}
+
+	name, module, err := s.probeModule(c.Query("module"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s\n", err)
+
+		return
+	}
+
+	s.probersMu.RLock()
+	prober, ok := s.probers[module.Prober]
+	s.probersMu.RUnlock()
+
+	if !ok {
+		c.String(http.StatusInternalServerError, "module %s uses unknown prober %q\n", name, module.Prober)
+
+		return
+	}
+
+	timeout := metrics.DefaultScrapeTimeout
+	if scrape, ok := scrapeTimeout(c.Request.Header); ok {
+		timeout = scrape
+	}
+
+	if module.Timeout.Duration > 0 && module.Timeout.Duration < timeout {
+		timeout = module.Timeout.Duration
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+
+	success := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Whether the probe succeeded",
+	})
+	duration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "Duration of the probe in seconds",
+	})
+	registry.MustRegister(success, duration)
+
+	start := time.Now()
+	err = prober.Probe(ctx, target, module, registry)
+	duration.Set(time.Since(start).Seconds())
+
+	if err != nil {
+		slog.Debug("Probe failed",
+			"target", target,
+			"module", name,
+			"error", err,
+		)
+	} else {
+		success.Set(1)
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}).ServeHTTP(c.Writer, c.Request)
+}
+
+// probeModule returns the configured module called name, or the only module
+// when name is empty
+func (s *Server) probeModule(name string) (string, config.ProbeModuleConfig, error) {
+	var modules map[string]config.ProbeModuleConfig
+	if provider, ok := s.currentConfig().(probeConfigProvider); ok {
+		modules = provider.GetProbe().Modules
+	}
+
+	if len(modules) == 0 {
+		return "", config.ProbeModuleConfig{}, fmt.Errorf("no probe modules are configured")
+	}
+
+	if name == "" {
+		if len(modules) != 1 {
+			return "", config.ProbeModuleConfig{}, fmt.Errorf("module parameter is missing")
+		}
+
+		for only, module := range modules {
+			return only, module, nil
+		}
+	}
+
+	module, ok := modules[name]
+	if !ok {
+		names := strings.Join(slices.Sorted(maps.Keys(modules)), ", ")
+
+		return "", config.ProbeModuleConfig{}, fmt.Errorf("unknown module %q (configured: %s)", name, names)
+	}
+
+	return name, module, nil
+}
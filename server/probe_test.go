@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestHandleProbe asserts a probe runs with its module's settings and
+// timeout, and reports its own metrics alongside probe_success.
+func TestHandleProbe(t *testing.T) {
+	cfg := &config.BaseConfig{}
+	cfg.Probe.Modules = map[string]config.ProbeModuleConfig{
+		"ping":   {Prober: "ping", Timeout: config.Duration{Duration: time.Second}, Params: map[string]string{"count": "3"}},
+		"broken": {Prober: "ping", Params: map[string]string{"fail": "true"}},
+	}
+
+	srv := New(cfg, metrics.NewRegistry("probe_test_info"), "test-exporter", nil, nil)
+
+	var timeout time.Duration
+
+	err := srv.RegisterProber("ping", ProberFunc(func(ctx context.Context, target string, module config.ProbeModuleConfig, registry *prometheus.Registry) error {
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+
+		if module.Params["fail"] == "true" {
+			return errors.New("no reply")
+		}
+
+		replies := prometheus.NewGauge(prometheus.GaugeOpts{Name: "ping_replies", Help: "Replies", ConstLabels: prometheus.Labels{"target": target}})
+		replies.Set(3)
+		registry.MustRegister(replies)
+
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("RegisterProber: %v", err)
+	}
+
+	probe := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/probe?"+query, nil)
+		req.Header.Set(scrapeTimeoutHeader, "5")
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := probe("target=router.local&module=ping")
+	body := rec.Body.String()
+
+	if rec.Code != http.StatusOK || !strings.Contains(body, "probe_success 1") || !strings.Contains(body, `ping_replies{target="router.local"} 3`) {
+		t.Errorf("expected a successful probe, got %d:\n%s", rec.Code, body)
+	}
+
+	if timeout <= 0 || timeout > time.Second {
+		t.Errorf("expected the module timeout to cap the scrape timeout, got %v", timeout)
+	}
+
+	if body := probe("target=router.local&module=broken").Body.String(); !strings.Contains(body, "probe_success 0") {
+		t.Errorf("expected a failed probe, got:\n%s", body)
+	}
+
+	for _, query := range []string{"module=ping", "target=router.local&module=http", "target=router.local"} {
+		if rec := probe(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %q, got %d", query, rec.Code)
+		}
+	}
+}
+
+// TestHandleProbe_NoProbers asserts /probe isn't served until a prober is
+// registered.
+func TestHandleProbe_NoProbers(t *testing.T) {
+	srv := New(&config.BaseConfig{}, metrics.NewRegistry("probe_test_info"), "test-exporter", nil, nil)
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe?target=router.local", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without probers, got %d", rec.Code)
+	}
+}
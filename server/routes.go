@@ -0,0 +1,138 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware wraps the handler of a custom route, e.g. to check a webhook
+// signature
+type Middleware func(http.Handler) http.Handler
+
+// RouteInfo describes a custom route, for listing on the dashboard
+type RouteInfo struct {
+	Method string
+	Path   string
+}
+
+// RouteGroup registers custom routes under a common path prefix, wrapped in
+// the group's middleware
+type RouteGroup struct {
+	server     *Server
+	prefix     string
+	middleware []Middleware
+}
+
+// Handle registers handler for method and path, wrapped in middleware. Path
+// parameters use gin's syntax, e.g. /api/targets/:name, and are available
+// to the handler through Request.PathValue. The framework's logging,
+// tracing, recovery and authentication apply as they do to the built-in
+// routes. Routes must be registered before the server starts, and a path
+// that is already registered is an error.
+func (s *Server) Handle(method, path string, handler http.Handler, middleware ...Middleware) error {
+	return s.Group("").Handle(method, path, handler, middleware...)
+}
+
+// Group returns a RouteGroup for routes under prefix, wrapped in middleware
+func (s *Server) Group(prefix string, middleware ...Middleware) *RouteGroup {
+	return &RouteGroup{server: s, prefix: prefix, middleware: middleware}
+}
+
+// Group returns a nested RouteGroup, whose middleware runs inside this
+// group's
+func (g *RouteGroup) Group(prefix string, middleware ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		server:     g.server,
+		prefix:     joinRoutePath(g.prefix, prefix),
+		middleware: append(slices.Clone(g.middleware), middleware...),
+	}
+}
+
+// Handle registers handler for method and path under the group's prefix.
+// The group's middleware runs before the route's own.
+func (g *RouteGroup) Handle(method, path string, handler http.Handler, middleware ...Middleware) error {
+	chain := append(slices.Clone(g.middleware), middleware...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+
+	return g.server.addRoute(method, joinRoutePath(g.prefix, path), handler)
+}
+
+// Routes returns the custom routes in the order they were registered
+func (s *Server) Routes() []RouteInfo {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+
+	return slices.Clone(s.routes)
+}
+
+// addRoute registers handler on the router, turning gin's panics for
+// invalid or duplicate routes into errors
+func (s *Server) addRoute(method, path string, handler http.Handler) (err error) {
+	s.mu.Lock()
+	started := s.server != nil || s.stopped
+	s.mu.Unlock()
+
+	if started {
+		return errors.New("routes must be registered before the server starts")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to register %s %s: %v", method, path, r)
+		}
+	}()
+
+	s.router.Handle(method, path, func(c *gin.Context) {
+		for _, param := range c.Params {
+			c.Request.SetPathValue(param.Key, param.Value)
+		}
+
+		handler.ServeHTTP(c.Writer, c.Request)
+	})
+
+	s.routesMu.Lock()
+	s.routes = append(s.routes, RouteInfo{Method: method, Path: path})
+	s.routesMu.Unlock()
+
+	return nil
+}
+
+// joinRoutePath joins a group prefix and a route path, keeping a trailing
+// slash on the route path
+func joinRoutePath(prefix, routePath string) string {
+	if prefix == "" {
+		return routePath
+	}
+
+	joined := path.Join("/", prefix, routePath)
+	if strings.HasSuffix(routePath, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+
+	return joined
+}
+
+// routeData returns the custom routes for the dashboard. Only GET routes
+// without path parameters are linked.
+func (s *Server) routeData() []RouteData {
+	routes := s.Routes()
+	data := make([]RouteData, 0, len(routes))
+
+	for _, route := range routes {
+		data = append(data, RouteData{
+			Method: route.Method,
+			Path:   route.Path,
+			Link:   route.Method == http.MethodGet && !strings.ContainsAny(route.Path, ":*"),
+		})
+	}
+
+	return data
+}
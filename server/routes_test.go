@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+)
+
+// TestHandle asserts custom routes run their group's middleware then their
+// own, receive path parameters, sit behind authentication and are listed on
+// the dashboard.
+func TestHandle(t *testing.T) {
+	cfg := &config.BaseConfig{}
+	cfg.Server.Auth.BearerTokens = []config.SensitiveString{config.NewSensitiveString("token")}
+
+	srv := New(cfg, metrics.NewRegistry("routes_test_info"), "test-exporter", nil, nil)
+
+	tag := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	api := srv.Group("/api", tag("group"))
+
+	err := api.Handle(http.MethodGet, "/targets/:name", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("target " + r.PathValue("name")))
+	}), tag("route"))
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	if err := srv.Handle(http.MethodPost, "/webhook", http.NotFoundHandler()); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	if err := srv.Handle(http.MethodGet, "/metrics", http.NotFoundHandler()); err == nil {
+		t.Error("expected an error for a path that is already registered")
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/targets/router")
+	if rec.Body.String() != "target router" {
+		t.Errorf("expected the path parameter to reach the handler, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if got := strings.Join(rec.Header().Values("X-Middleware"), ","); got != "group,route" {
+		t.Errorf("expected group middleware before route middleware, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/targets/router", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected custom routes to require authentication, got %d", rec.Code)
+	}
+
+	body := serve(http.MethodGet, "/").Body.String()
+	if !strings.Contains(body, "/api/targets/:name") || !strings.Contains(body, "/webhook") {
+		t.Error("expected the custom routes to be listed on the dashboard")
+	}
+}
+
+// TestHandle_AdminPrefixRequiresAuth asserts only the built-in admin
+// endpoints skip the exporter's authentication, not custom routes under
+// /admin.
+func TestHandle_AdminPrefixRequiresAuth(t *testing.T) {
+	cfg := &config.BaseConfig{}
+	cfg.Server.Auth.BearerTokens = []config.SensitiveString{config.NewSensitiveString("token")}
+	cfg.Server.Admin.Token = config.NewSensitiveString("admin-token")
+
+	srv := New(cfg, metrics.NewRegistry("routes_test_info"), "test-exporter", nil, nil)
+
+	if err := srv.Handle(http.MethodGet, "/admin/anything", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("custom"))
+	})); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	serve := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	for _, tt := range []struct {
+		path, token string
+		want        int
+	}{
+		{"/admin/anything", "", http.StatusUnauthorized},
+		{"/admin/anything", "admin-token", http.StatusUnauthorized},
+		{"/admin/anything", "token", http.StatusOK},
+		{"/admin/loglevel", "admin-token", http.StatusOK},
+	} {
+		if got := serve(tt.path, tt.token); got != tt.want {
+			t.Errorf("%s with token %q: expected %d, got %d", tt.path, tt.token, tt.want, got)
+		}
+	}
+}
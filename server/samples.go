@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// maxSamplesPerMetric caps how many series are shown for each metric, so a
+// high-cardinality metric doesn't produce a huge page
+const maxSamplesPerMetric = 5
+
+// gatherFamilies gathers the registry as it is exposed on /metrics, keyed by
+// metric name, running scrape collectors with ctx. Gathering errors are
+// logged and whatever was gathered is still returned.
+func (s *Server) gatherFamilies(ctx context.Context) map[string]*dto.MetricFamily {
+	families, err := s.metrics.GathererWithContext(ctx).Gather()
+	if err != nil {
+		slog.Debug("Failed to gather all metrics for the dashboard", "error", err)
+	}
+
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	return byName
+}
+
+// metricSamples returns up to maxSamplesPerMetric series of family for the
+// dashboard
+func metricSamples(family *dto.MetricFamily) []SampleData {
+	samples := make([]SampleData, 0, min(len(family.Metric), maxSamplesPerMetric))
+
+	for _, metric := range family.Metric {
+		if len(samples) == maxSamplesPerMetric {
+			break
+		}
+
+		samples = append(samples, SampleData{
+			Labels: formatLabels(metric.Label),
+			Value:  formatSample(family.GetType(), metric),
+		})
+	}
+
+	return samples
+}
+
+// formatLabels returns label pairs in exposition format, e.g.
+// {method="GET",status="200"}, or an empty string if there are none
+func formatLabels(pairs []*dto.LabelPair) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	labels := make([]string, len(pairs))
+	for i, pair := range pairs {
+		labels[i] = fmt.Sprintf("%s=%q", pair.GetName(), pair.GetValue())
+	}
+
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// formatSample returns the value of a series. Histograms and summaries are
+// shown as their count and sum with their buckets or quantiles.
+func formatSample(metricType dto.MetricType, metric *dto.Metric) string {
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		return formatFloat(metric.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		return formatFloat(metric.GetGauge().GetValue())
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		histogram := metric.GetHistogram()
+
+		buckets := make([]string, 0, len(histogram.Bucket)+1)
+		for _, bucket := range histogram.Bucket {
+			buckets = append(buckets, fmt.Sprintf("≤%s: %d", formatFloat(bucket.GetUpperBound()), bucket.GetCumulativeCount()))
+		}
+
+		// The +Inf bucket is implicit in the gathered histogram
+		buckets = append(buckets, fmt.Sprintf("+Inf: %d", histogram.GetSampleCount()))
+
+		return fmt.Sprintf("count %d, sum %s; buckets %s",
+			histogram.GetSampleCount(), formatFloat(histogram.GetSampleSum()), strings.Join(buckets, ", "))
+	case dto.MetricType_SUMMARY:
+		summary := metric.GetSummary()
+
+		quantiles := make([]string, 0, len(summary.Quantile))
+		for _, quantile := range summary.Quantile {
+			quantiles = append(quantiles, fmt.Sprintf("p%s: %s", formatFloat(quantile.GetQuantile()*100), formatFloat(quantile.GetValue())))
+		}
+
+		formatted := fmt.Sprintf("count %d, sum %s", summary.GetSampleCount(), formatFloat(summary.GetSampleSum()))
+		if len(quantiles) > 0 {
+			formatted += "; " + strings.Join(quantiles, ", ")
+		}
+
+		return formatted
+	default:
+		return formatFloat(metric.GetUntyped().GetValue())
+	}
+}
+
+// formatFloat formats a sample value as it appears in the exposition format
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestHandleRoot_ShowsLiveSamples asserts the dashboard shows the current
+// series of each metric, capped per metric, and histogram buckets.
+func TestHandleRoot_ShowsLiveSamples(t *testing.T) {
+	registry := metrics.NewRegistry("samples_test_info")
+
+	requests, err := registry.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests"}, []string{"path"})
+	if err != nil {
+		t.Fatalf("NewCounterVec: %v", err)
+	}
+
+	for i := range maxSamplesPerMetric + 2 {
+		requests.WithLabelValues(fmt.Sprintf("/%d", i)).Add(float64(i))
+	}
+
+	latency, err := registry.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "latency_seconds",
+		Help:    "Latency",
+		Buckets: []float64{0.1, 1},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewHistogramVec: %v", err)
+	}
+
+	latency.WithLabelValues().Observe(0.5)
+
+	if _, err := registry.NewGaugeVec(prometheus.GaugeOpts{Name: "idle", Help: "Never set"}, []string{"x"}); err != nil {
+		t.Fatalf("NewGaugeVec: %v", err)
+	}
+
+	srv := New(&config.BaseConfig{}, registry, "test-exporter", nil, nil)
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := html.UnescapeString(rec.Body.String())
+
+	for _, want := range []string{
+		"7 series, showing the first 5:",
+		`requests_total{path="/4"} 4`,
+		"latency_seconds count 1, sum 0.5; buckets ≤0.1: 0, ≤1: 1, +Inf: 1",
+		"No series yet",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the dashboard", want)
+		}
+	}
+
+	if strings.Contains(body, `requests_total{path="/5"}`) {
+		t.Error("expected the samples to be capped")
+	}
+}
+
+// TestHandleCardinality asserts the cardinality page lists the metrics with
+// the most series first.
+func TestHandleCardinality(t *testing.T) {
+	registry := metrics.NewRegistry("cardinality_test_info")
+
+	users, err := registry.NewGaugeVec(prometheus.GaugeOpts{Name: "aaa_users", Help: "Users"}, []string{"id"})
+	if err != nil {
+		t.Fatalf("NewGaugeVec: %v", err)
+	}
+
+	for i := range 1000 {
+		users.WithLabelValues(fmt.Sprint(i)).Set(1)
+	}
+
+	srv := New(&config.BaseConfig{}, registry, "test-exporter", nil, nil)
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cardinality?top=1", nil))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if rec.Code != http.StatusOK || len(lines) != 4 || !strings.HasSuffix(lines[3], "aaa_users") || !strings.Contains(lines[3], "1000") {
+		t.Errorf("expected aaa_users to be listed alone, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
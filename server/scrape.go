@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrapeTimeoutHeader is set by Prometheus to the scrape timeout in seconds
+const scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// scrapeTimeoutOffset is taken off the scrape timeout, so that scrape
+// collectors give up in time for the response to reach Prometheus
+const scrapeTimeoutOffset = 500 * time.Millisecond
+
+// handleMetrics serves the registry, running scrape collectors with the
+// scrape's timeout
+func (s *Server) handleMetrics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if timeout, ok := scrapeTimeout(c.Request.Header); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	promhttp.HandlerFor(s.metrics.GathererWithContext(ctx), promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}).ServeHTTP(c.Writer, c.Request)
+}
+
+// scrapeTimeout returns the timeout Prometheus set for this scrape, less
+// scrapeTimeoutOffset when the timeout is long enough to allow it
+func scrapeTimeout(header http.Header) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(header.Get(scrapeTimeoutHeader), 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds <= 0 {
+		return 0, false
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > 2*scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+
+	return timeout, true
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScrapeTimeout(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "10", want: 9500 * time.Millisecond, ok: true},
+		{header: "0.5", want: 500 * time.Millisecond, ok: true},
+		{header: "", ok: false},
+		{header: "-1", ok: false},
+		{header: "NaN", ok: false},
+		{header: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set(scrapeTimeoutHeader, tt.header)
+
+		got, ok := scrapeTimeout(header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("scrapeTimeout(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
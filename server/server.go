@@ -2,18 +2,25 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/health"
+	"github.com/d0ugal/promexporter/logging"
 	"github.com/d0ugal/promexporter/metrics"
 	"github.com/d0ugal/promexporter/tracing"
 	"github.com/d0ugal/promexporter/version"
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
@@ -31,15 +38,40 @@ type CustomConfigRenderer interface {
 	RenderConfigHTML(key string, value interface{}) (string, bool)
 }
 
+// HealthSource reports the current status of the exporter's collectors
+type HealthSource func() []health.Status
+
 // Server handles HTTP requests and serves metrics
 type Server struct {
-	config      ConfigInterface
-	metrics     *metrics.Registry
-	server      *http.Server
-	router      *gin.Engine
-	name        string
-	versionInfo *version.Info
-	tracer      *tracing.Tracer
+	config       ConfigInterface
+	metrics      *metrics.Registry
+	server       *http.Server
+	router       *gin.Engine
+	name         string
+	versionInfo  *version.Info
+	tracer       *tracing.Tracer
+	healthSource HealthSource
+	authCache    *authCache
+
+	// Probers for /probe by name, guarded by probersMu. The endpoint is
+	// only registered once there is a prober.
+	probers   map[string]Prober
+	probersMu sync.RWMutex
+
+	// Custom routes registered by the exporter, for the dashboard
+	routes   []RouteInfo
+	routesMu sync.RWMutex
+
+	// mu guards server and stopped, which are touched by Start and
+	// Shutdown from different goroutines
+	mu      sync.Mutex
+	stopped bool
+
+	// configMu guards config and auth, which are replaced on reload. auth
+	// is resolved from config when it is set, as it may come from the web
+	// config file.
+	configMu sync.RWMutex
+	auth     *config.AuthConfig
 }
 
 // New creates a new server instance
@@ -61,14 +93,31 @@ func New(cfg ConfigInterface, metricsRegistry *metrics.Registry, exporterName st
 	router.Use(customGinLogger(), gin.Recovery())
 
 	server := &Server{
-		config:      cfg,
-		metrics:     metricsRegistry,
-		router:      router,
-		name:        exporterName,
-		versionInfo: customVersionInfo,
-		tracer:      tracer,
+		config:       cfg,
+		metrics:      metricsRegistry,
+		router:       router,
+		name:         exporterName,
+		versionInfo:  customVersionInfo,
+		tracer:       tracer,
+		healthSource: func() []health.Status { return nil },
+		authCache:    newAuthCache(),
+	}
+
+	// Serve fails if the web config file can't be read, so the fallback
+	// here is never used to serve requests
+	auth, err := cfg.GetServer().ResolveAuth()
+	if err != nil {
+		slog.Error("Failed to resolve authentication settings", "error", err)
+
+		auth = &cfg.GetServer().Auth
 	}
 
+	server.auth = auth
+
+	// Authentication applies to every route, including any registered
+	// later, so it is added before the routes are set up
+	router.Use(server.authenticate)
+
 	server.setupRoutes()
 
 	return server
@@ -91,58 +140,179 @@ func customGinLogger() gin.HandlerFunc {
 	})
 }
 
-// Start starts the HTTP server
+// SetConfig replaces the configuration, e.g. after a reload. Settings that
+// are only read at startup, such as the listen address and which routes are
+// enabled, still require a restart.
+func (s *Server) SetConfig(cfg ConfigInterface) {
+	auth, err := cfg.GetServer().ResolveAuth()
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	s.config = cfg
+
+	if err != nil {
+		slog.Error("Failed to resolve authentication settings, keeping the previous ones", "error", err)
+		return
+	}
+
+	s.auth = auth
+}
+
+// currentConfig returns the active configuration
+func (s *Server) currentConfig() ConfigInterface {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	return s.config
+}
+
+// currentAuth returns the active authentication settings
+func (s *Server) currentAuth() *config.AuthConfig {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	return s.auth
+}
+
+// SetHealthSource sets the function used to report collector status on the
+// /health and /ready endpoints
+func (s *Server) SetHealthSource(source HealthSource) {
+	s.healthSource = source
+}
+
+// Start listens on the configured host and port and serves HTTP requests.
+// It returns http.ErrServerClosed after a graceful shutdown, including when
+// Shutdown was called before Start.
 func (s *Server) Start() error {
-	serverConfig := s.config.GetServer()
+	serverConfig := s.currentConfig().GetServer()
 	addr := fmt.Sprintf("%s:%d", serverConfig.Host, serverConfig.Port)
 
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve serves HTTP requests on an existing listener, which is closed when
+// the server shuts down. This allows callers to bind the socket themselves,
+// e.g. to learn the address of an OS-assigned port before serving.
+func (s *Server) Serve(listener net.Listener) error {
+	serverConfig := s.currentConfig().GetServer()
+
+	tlsSettings, err := serverConfig.ResolveTLS()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	auth, err := serverConfig.ResolveAuth()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	s.configMu.Lock()
+	s.auth = auth
+	s.configMu.Unlock()
+
+	var tlsConfig *tls.Config
+
+	if tlsSettings.IsEnabled() {
+		tlsConfig, err = newTLSConfig(tlsSettings)
+		if err != nil {
+			_ = listener.Close()
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		_ = listener.Close()
+
+		return http.ErrServerClosed
+	}
+
 	s.server = &http.Server{
-		Addr:              addr,
+		Addr:              listener.Addr().String(),
 		Handler:           s.router,
 		ReadHeaderTimeout: 30 * time.Second,
+		TLSConfig:         tlsConfig,
 	}
+	httpServer := s.server
+	s.mu.Unlock()
 
 	slog.Info("Starting exporter server",
 		"name", s.name,
-		"address", addr,
+		"address", listener.Addr().String(),
+		"tls", tlsConfig != nil,
 	)
 
-	return s.server.ListenAndServe()
+	if tlsConfig != nil {
+		// The certificate is provided by tlsConfig, so no files are passed
+		return httpServer.ServeTLS(listener, "", "")
+	}
+
+	return httpServer.Serve(listener)
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the server, waiting at most
+// server.shutdown_timeout for in-flight requests to complete
 func (s *Server) Shutdown() error {
-	if s.server != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), s.currentConfig().GetServer().GetShutdownTimeout())
+	defer cancel()
 
-		if err := s.server.Shutdown(ctx); err != nil {
-			slog.Error("Server shutdown error", "error", err)
-			return err
-		} else {
-			slog.Info("Server shutdown gracefully")
-		}
+	return s.ShutdownContext(ctx)
+}
+
+// ShutdownContext stops accepting new connections and waits for in-flight
+// requests to complete until ctx is done
+func (s *Server) ShutdownContext(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	httpServer := s.server
+	s.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Error("Server shutdown error", "error", err)
+		return err
 	}
 
+	slog.Info("Server shutdown gracefully")
+
 	return nil
 }
 
 func (s *Server) setupRoutes() {
-	// Root endpoint with HTML dashboard (optional)
+	// Root endpoint with HTML dashboard and the cardinality page (optional)
 	if s.config.GetServer().IsWebUIEnabled() {
 		s.router.GET("/", s.handleRoot)
+		s.router.GET("/debug/cardinality", s.handleCardinality)
 	}
 
-	// Metrics endpoint - use our custom registry
-	s.router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(s.metrics.GetRegistry(), promhttp.HandlerOpts{
-		EnableOpenMetrics: true,
-	})))
+	// Metrics endpoint - use our custom registry (optional, for exporters
+	// that only push)
+	if s.config.GetServer().IsMetricsEnabled() {
+		s.router.GET("/metrics", s.handleMetrics)
+	}
 
 	// Health endpoint (optional)
 	if s.config.GetServer().IsHealthEnabled() {
 		s.router.GET("/health", s.handleHealth)
 		s.router.HEAD("/health", s.handleHealth)
+		s.router.GET("/ready", s.handleReady)
+		s.router.HEAD("/ready", s.handleReady)
 	}
+
+	// Admin endpoints (only when an admin token is configured)
+	s.setupAdminRoutes()
 }
 
 func (s *Server) handleRoot(c *gin.Context) {
@@ -155,26 +325,39 @@ func (s *Server) handleRoot(c *gin.Context) {
 	}
 
 	metricsInfo := s.metrics.GetMetricsInfo()
+	families := s.gatherFamilies(c.Request.Context())
 
-	// Convert metrics to template data
+	// Convert metrics to template data, with their current series
 	metrics := make([]MetricData, 0, len(metricsInfo))
 	for _, metric := range metricsInfo {
-		metrics = append(metrics, MetricData{
+		data := MetricData{
 			Name:         metric.Name,
 			Help:         metric.Help,
+			Type:         metric.Type,
 			Labels:       metric.Labels,
+			Buckets:      formatBuckets(metric.Buckets),
 			ExampleValue: metric.ExampleValue,
-		})
+		}
+
+		if family, ok := families[metric.Name]; ok {
+			data.SeriesCount = len(family.Metric)
+			data.Samples = metricSamples(family)
+			data.MoreSeries = data.SeriesCount - len(data.Samples)
+		}
+
+		metrics = append(metrics, data)
 	}
 
 	data := TemplateData{
-		ExporterName: s.name,
-		Version:      versionInfo.Version,
-		Commit:       versionInfo.Commit,
-		BuildDate:    versionInfo.BuildDate,
-		Status:       "ready",
-		Config:       s.getConfigData(),
-		Metrics:      metrics,
+		ExporterName:  s.name,
+		Version:       versionInfo.Version,
+		Commit:        versionInfo.Commit,
+		BuildDate:     versionInfo.BuildDate,
+		Status:        readinessStatus(s.healthSource()),
+		Config:        s.getConfigData(),
+		ConfigSources: s.getConfigSources(),
+		Metrics:       metrics,
+		Routes:        s.routeData(),
 	}
 
 	c.Header("Content-Type", "text/html")
@@ -195,51 +378,148 @@ func (s *Server) handleHealth(c *gin.Context) {
 		versionInfo = version.Get()
 	}
 
+	// /health is a liveness check, so it always returns 200. Failing
+	// collectors are reported as "degraded" for visibility; use /ready to
+	// take the exporter out of service while collectors are failing.
+	statuses := s.healthSource()
+
+	status := "healthy"
+	if health.AnyFailing(statuses) {
+		status = "degraded"
+	}
+
 	response := gin.H{
-		"status":     "healthy",
+		"status":     status,
 		"timestamp":  time.Now().Unix(),
 		"service":    s.name,
 		"version":    versionInfo.Version,
 		"commit":     versionInfo.Commit,
 		"build_date": versionInfo.BuildDate,
+		"collectors": collectorStatusResponse(statuses),
+	}
+
+	writeHealthResponse(c, http.StatusOK, response)
+}
+
+// handleReady reports whether the exporter has data to serve. It returns
+// 503 until every required collector has completed a first collection, and
+// whenever a required collector is failing.
+func (s *Server) handleReady(c *gin.Context) {
+	statuses := s.healthSource()
+	status := readinessStatus(statuses)
+
+	code := http.StatusOK
+	if status != "ready" {
+		code = http.StatusServiceUnavailable
+	}
+
+	response := gin.H{
+		"status":     status,
+		"timestamp":  time.Now().Unix(),
+		"service":    s.name,
+		"collectors": collectorStatusResponse(statuses),
+	}
+
+	writeHealthResponse(c, code, response)
+}
+
+// readinessStatus summarises collector statuses as "ready" or "not_ready"
+func readinessStatus(statuses []health.Status) string {
+	if health.AllReady(statuses) {
+		return "ready"
 	}
 
+	return "not_ready"
+}
+
+// writeHealthResponse writes a JSON health response, omitting the body for
+// HEAD requests
+func writeHealthResponse(c *gin.Context, code int, response gin.H) {
 	// For HEAD requests, set status and headers but don't write body
 	if c.Request.Method == http.MethodHead {
-		c.Status(http.StatusOK)
+		c.Status(code)
 		c.Header("Content-Type", "application/json; charset=utf-8")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(code, response)
+}
+
+// collectorStatusResponse converts collector statuses to their JSON form
+func collectorStatusResponse(statuses []health.Status) []gin.H {
+	collectors := make([]gin.H, 0, len(statuses))
+
+	for _, status := range statuses {
+		collector := gin.H{
+			"name":                 status.Name,
+			"required":             status.Required,
+			"ready":                status.Ready(),
+			"consecutive_failures": status.ConsecutiveFailures,
+			"last_success":         nil,
+			"last_error":           nil,
+			"last_error_time":      nil,
+		}
+
+		if !status.LastSuccess.IsZero() {
+			collector["last_success"] = status.LastSuccess.UTC().Format(time.RFC3339)
+		}
+
+		if status.LastError != "" {
+			collector["last_error"] = status.LastError
+			collector["last_error_time"] = status.LastErrorTime.UTC().Format(time.RFC3339)
+		}
+
+		collectors = append(collectors, collector)
+	}
+
+	return collectors
+}
+
+// formatBuckets returns histogram buckets as a comma-separated list
+func formatBuckets(buckets []float64) string {
+	formatted := make([]string, len(buckets))
+	for i, bucket := range buckets {
+		formatted[i] = strconv.FormatFloat(bucket, 'g', -1, 64)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// getConfigSources lists the configuration values that were not left at
+// their defaults, with where each came from. It is empty unless the config
+// was built by config.Loader.
+func (s *Server) getConfigSources() []ConfigSourceData {
+	provider, ok := s.currentConfig().(interface{ Provenance() map[string]string })
+	if !ok {
+		return nil
+	}
+
+	provenance := provider.Provenance()
+	sources := make([]ConfigSourceData, 0, len(provenance))
+
+	for path, source := range provenance {
+		if source != config.SourceDefault {
+			sources = append(sources, ConfigSourceData{Path: path, Source: source})
+		}
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Path < sources[j].Path })
+
+	return sources
 }
 
 // getConfigData returns configuration data for the template
 // Uses the BaseConfig's GetDisplayConfig method and adds sensitivity information
 func (s *Server) getConfigData() map[string]interface{} {
-	config := s.config.GetDisplayConfig()
+	cfg := s.currentConfig()
+	config := cfg.GetDisplayConfig()
 
-	// Add sensitivity information and custom HTML to each config value
 	for key, value := range config {
-		// Check if the value implements SensitiveValue interface
-		if sensitiveValue, ok := value.(interface{ IsSensitive() bool }); ok && sensitiveValue.IsSensitive() {
-			// Wrap sensitive values with metadata
-			config[key] = map[string]interface{}{
-				"value":     value,
-				"sensitive": true,
-			}
-		} else {
-			// For non-sensitive values, preserve the original data structure
-			// but add sensitivity metadata
-			config[key] = map[string]interface{}{
-				"value":     value,
-				"sensitive": false,
-			}
-		}
+		config[key] = configItem(key, value)
 
 		// Check if the config implements CustomConfigRenderer
-		if renderer, ok := s.config.(CustomConfigRenderer); ok {
+		if renderer, ok := cfg.(CustomConfigRenderer); ok {
 			if customHTML, hasCustom := renderer.RenderConfigHTML(key, value); hasCustom {
 				// Add custom HTML fragment to the config value as template.HTML to prevent escaping
 				// SECURITY NOTE: This bypasses HTML escaping. The RenderConfigHTML method should only
@@ -253,3 +533,36 @@ func (s *Server) getConfigData() map[string]interface{} {
 
 	return config
 }
+
+// configItem wraps a display config value with its sensitivity. Nested
+// sections have each of their entries wrapped under "section".
+func configItem(key string, value interface{}) map[string]interface{} {
+	if section, ok := value.(map[string]interface{}); ok {
+		items := make(map[string]interface{}, len(section))
+		for name, entry := range section {
+			items[name] = configItem(name, entry)
+		}
+
+		return map[string]interface{}{
+			"section":   items,
+			"sensitive": false,
+		}
+	}
+
+	// Check if the value implements SensitiveValue interface
+	if sensitiveValue, ok := value.(interface{ IsSensitive() bool }); ok && sensitiveValue.IsSensitive() {
+		// Wrap sensitive values with metadata
+		return map[string]interface{}{
+			"value":     value,
+			"sensitive": true,
+		}
+	}
+
+	// For other values, preserve the original data structure but redact
+	// plain strings under sensitive keys (such as "API Token") and
+	// credentials embedded in URLs
+	return map[string]interface{}{
+		"value":     logging.RedactValue(key, value),
+		"sensitive": logging.IsSensitiveKey(key),
+	}
+}
@@ -2,11 +2,15 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/health"
 	"github.com/d0ugal/promexporter/metrics"
 	"github.com/d0ugal/promexporter/version"
 )
@@ -74,3 +78,86 @@ func TestHandleHealth_UsesConfiguredVersionInfo(t *testing.T) {
 		t.Errorf("build_date: want %q, got %q", configured.BuildDate, got)
 	}
 }
+
+// TestHandleReady_ReflectsCollectorHealth asserts that /ready returns 503
+// until required collectors have succeeded, while /health stays 200.
+func TestHandleReady_ReflectsCollectorHealth(t *testing.T) {
+	cfg := &minimalConfig{
+		server: &config.ServerConfig{Host: "127.0.0.1", Port: 0},
+	}
+	registry := metrics.NewRegistry("server_test_info")
+
+	srv := New(cfg, registry, "test-exporter", nil, nil)
+
+	tracker := health.NewTracker()
+	tracker.Register("optional", false)
+	tracker.Register("required", true)
+	srv.SetHealthSource(tracker.Statuses)
+
+	get := func(path string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+
+		srv.router.ServeHTTP(rec, req)
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unmarshal %s body: %v", path, err)
+		}
+
+		return rec.Code, body
+	}
+
+	if code, body := get("/ready"); code != http.StatusServiceUnavailable || body["status"] != "not_ready" {
+		t.Errorf("before first collection: want 503 not_ready, got %d %v", code, body["status"])
+	}
+
+	if code, _ := get("/health"); code != http.StatusOK {
+		t.Errorf("/health must stay 200 for liveness, got %d", code)
+	}
+
+	tracker.RecordSuccess("required", time.Now())
+
+	code, body := get("/ready")
+	if code != http.StatusOK || body["status"] != "ready" {
+		t.Errorf("after success: want 200 ready, got %d %v", code, body["status"])
+	}
+
+	if collectors, ok := body["collectors"].([]interface{}); !ok || len(collectors) != 2 {
+		t.Errorf("expected 2 collectors in response, got %v", body["collectors"])
+	}
+
+	for i := 0; i < health.FailureThreshold; i++ {
+		tracker.RecordFailure("required", errors.New("unreachable"), time.Now())
+	}
+
+	if code, _ := get("/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("after repeated failures: want 503, got %d", code)
+	}
+
+	if _, body := get("/health"); body["status"] != "degraded" {
+		t.Errorf("/health status: want degraded, got %v", body["status"])
+	}
+}
+
+// TestHandleRoot_RendersNestedConfig asserts the dashboard renders the
+// nested display config sections without leaking sensitive values.
+func TestHandleRoot_RendersNestedConfig(t *testing.T) {
+	cfg := &config.BaseConfig{}
+	cfg.Server.Port = 9123
+	cfg.Server.Admin.Token = config.NewSensitiveString("s3cret")
+
+	srv := New(cfg, metrics.NewRegistry("server_test_display"), "test-exporter", nil, nil)
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "9123") || !strings.Contains(body, "config-section") {
+		t.Errorf("expected the nested server section, got %d: %s", rec.Code, body)
+	}
+
+	if strings.Contains(body, "s3cret") {
+		t.Error("the dashboard leaked the admin token")
+	}
+}
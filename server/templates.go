@@ -10,21 +10,47 @@ var templateFS embed.FS
 
 // TemplateData holds the data passed to the HTML template
 type TemplateData struct {
-	ExporterName string
-	Version      string
-	Commit       string
-	BuildDate    string
-	Status       string
-	Config       map[string]interface{}
-	Metrics      []MetricData
+	ExporterName  string
+	Version       string
+	Commit        string
+	BuildDate     string
+	Status        string
+	Config        map[string]interface{}
+	ConfigSources []ConfigSourceData
+	Metrics       []MetricData
+	Routes        []RouteData
+}
+
+// ConfigSourceData records where a configuration value came from
+type ConfigSourceData struct {
+	Path   string
+	Source string
+}
+
+// RouteData represents a custom route on the dashboard
+type RouteData struct {
+	Method string
+	Path   string
+	Link   bool // Whether the route can be opened from the dashboard
 }
 
 // MetricData represents a metric for template rendering
 type MetricData struct {
 	Name         string
 	Help         string
+	Type         string
 	Labels       []string
+	Buckets      string
 	ExampleValue string
+	SeriesCount  int          // Number of series currently exposed
+	Samples      []SampleData // The first few series
+	MoreSeries   int          // Series not included in Samples
+}
+
+// SampleData is a single series of a metric shown in the dashboard
+type SampleData struct {
+	Labels string // In exposition format, e.g. {method="GET"}
+	Value  string
 }
 
 var mainTemplate = template.Must(template.New("index.html").Funcs(template.FuncMap{
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/promexporter/config"
+)
+
+// certReloader provides the server certificate and client CA pool, re-reading
+// the files whenever their modification time changes so that rotated
+// certificates are picked up without a restart
+type certReloader struct {
+	settings *config.TLSConfig
+	base     *tls.Config
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  [3]time.Time
+}
+
+// newTLSConfig builds a tls.Config from settings. The certificate and client
+// CA are loaded immediately so that configuration errors surface at startup.
+func newTLSConfig(settings *config.TLSConfig) (*tls.Config, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Errors were checked by Validate above
+	clientAuth, _ := settings.ClientAuth()
+	minVersion, _ := settings.MinTLSVersion()
+	maxVersion, _ := settings.MaxTLSVersion()
+	cipherSuites, _ := settings.CipherSuiteIDs()
+	curveIDs, _ := settings.CurveIDs()
+
+	reloader := &certReloader{
+		settings: settings,
+		base: &tls.Config{
+			ClientAuth:       clientAuth,
+			MinVersion:       minVersion,
+			MaxVersion:       maxVersion,
+			CipherSuites:     cipherSuites,
+			CurvePreferences: curveIDs,
+			NextProtos:       []string{"h2", "http/1.1"},
+		},
+	}
+
+	if _, err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := reloader.base.Clone()
+	tlsConfig.GetConfigForClient = reloader.getConfigForClient
+
+	return tlsConfig, nil
+}
+
+// getConfigForClient returns the config for a new connection, using the
+// current certificate and client CA pool
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	cert, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	clientCAs := r.clientCAs
+	r.mu.Unlock()
+
+	tlsConfig := r.base.Clone()
+	tlsConfig.Certificates = []tls.Certificate{*cert}
+	tlsConfig.ClientCAs = clientCAs
+
+	return tlsConfig, nil
+}
+
+// load returns the current certificate, reloading the files if they have
+// changed. If a reload fails the previously loaded files are kept.
+func (r *certReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes, err := r.statFiles()
+	if err == nil && r.cert != nil && modTimes == r.modTimes {
+		return r.cert, nil
+	}
+
+	if err == nil {
+		err = r.reload(modTimes)
+	}
+
+	if err != nil {
+		if r.cert == nil {
+			return nil, err
+		}
+
+		slog.Warn("Failed to reload TLS certificate, keeping the previous one", "error", err)
+	}
+
+	return r.cert, nil
+}
+
+// reload reads the certificate, key and client CA files. Callers must hold mu.
+func (r *certReloader) reload(modTimes [3]time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.settings.CertFile, r.settings.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+
+	if r.settings.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.settings.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.settings.ClientCAFile)
+		}
+	}
+
+	if r.cert != nil {
+		slog.Info("Reloaded TLS certificate", "cert_file", r.settings.CertFile)
+	}
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+
+	return nil
+}
+
+// statFiles returns the modification times of the certificate, key and
+// client CA files
+func (r *certReloader) statFiles() ([3]time.Time, error) {
+	var modTimes [3]time.Time
+
+	for i, path := range []string{r.settings.CertFile, r.settings.KeyFile, r.settings.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, err
+		}
+
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/promexporter/config"
+	"github.com/d0ugal/promexporter/metrics"
+)
+
+// writeSelfSignedCert writes a self-signed certificate with the given serial
+// number and its key to certFile and keyFile
+func writeSelfSignedCert(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
+
+// TestServe_TLSReloadsRotatedCertificate asserts the server serves TLS with
+// the configured certificate and picks up a replaced certificate on new
+// connections without a restart.
+func TestServe_TLSReloadsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeSelfSignedCert(t, certFile, keyFile, 1)
+
+	cfg := &minimalConfig{
+		server: &config.ServerConfig{
+			Host: "127.0.0.1",
+			TLS:  config.TLSConfig{CertFile: certFile, KeyFile: keyFile},
+		},
+	}
+	srv := New(cfg, metrics.NewRegistry("server_test_info"), "test-exporter", nil, nil)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go func() { _ = srv.Serve(listener) }()
+
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // self-signed test certificate
+			DisableKeepAlives: true,
+		},
+	}
+
+	servedSerial := func() int64 {
+		t.Helper()
+
+		resp, err := client.Get("https://" + listener.Addr().String() + "/health")
+		if err != nil {
+			t.Fatalf("GET /health: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+			t.Fatal("expected a TLS connection with a peer certificate")
+		}
+
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if got := servedSerial(); got != 1 {
+		t.Fatalf("expected initial certificate serial 1, got %d", got)
+	}
+
+	writeSelfSignedCert(t, certFile, keyFile, 2)
+
+	// Make sure the modification time changes even on coarse filesystems
+	future := time.Now().Add(time.Minute)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, future, future); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	if got := servedSerial(); got != 2 {
+		t.Errorf("expected rotated certificate serial 2, got %d", got)
+	}
+}
+
+// TestTLSConfig_Validate asserts inconsistent TLS settings are rejected.
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings config.TLSConfig
+	}{
+		{"key without cert", config.TLSConfig{KeyFile: "tls.key"}},
+		{"cert without key", config.TLSConfig{CertFile: "tls.crt"}},
+		{"verify without CA", config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuthType: "RequireAndVerifyClientCert"}},
+		{"unknown version", config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", MinVersion: "SSL3"}},
+		{"min above max", config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", MinVersion: "TLS13", MaxVersion: "TLS12"}},
+		{"unknown cipher", config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.settings.Validate(); err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
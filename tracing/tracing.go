@@ -67,7 +67,7 @@ func NewTracer(cfg *config.TracingConfig) (*Tracer, error) {
 			context.Background(),
 			otlptracehttp.WithEndpoint(endpointURL.Host),
 			otlptracehttp.WithURLPath(endpointURL.Path),
-			otlptracehttp.WithHeaders(cfg.Headers),
+			otlptracehttp.WithHeaders(cfg.HeaderValues()),
 		)
 	} else {
 		// For HTTP endpoints, use WithInsecure
@@ -75,7 +75,7 @@ func NewTracer(cfg *config.TracingConfig) (*Tracer, error) {
 			context.Background(),
 			otlptracehttp.WithEndpoint(endpointURL.Host),
 			otlptracehttp.WithURLPath(endpointURL.Path),
-			otlptracehttp.WithHeaders(cfg.Headers),
+			otlptracehttp.WithHeaders(cfg.HeaderValues()),
 			otlptracehttp.WithInsecure(),
 		)
 	}